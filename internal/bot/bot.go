@@ -3,7 +3,9 @@ package bot
 import (
 	"context"
 	"github.com/Enthreeka/tg-question-bot/internal/config"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/callback"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/middleware"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/view"
@@ -14,8 +16,11 @@ import (
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
 	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
 	"log"
+	"sync"
 	"time"
 )
 
@@ -24,20 +29,56 @@ const (
 )
 
 type Bot struct {
-	bot           *tgbotapi.BotAPI
-	psql          *postgres.Postgres
-	store         *store.Store
-	excel         *excel.Excel
-	cfg           *config.Config
-	log           *logger.Logger
-	tgMsg         *customMsg.TelegramMsg
-	callbackStore *store.CallbackStorage
-
-	userService service.UserService
-	userRepo    repo.UserRepo
-
-	callbackUser callback.CallbackUser
-	viewGeneral  *view.ViewGeneral
+	bot     *tgbotapi.BotAPI
+	psql    *postgres.Postgres
+	store   store.LocalStorage
+	dialogs *dialog.Engine
+	tgBot   *tgbot.Bot
+	redis   *redis.Client
+	excel   *excel.Excel
+	cfg     *config.Config
+	log     *logger.Logger
+	tgMsg   *customMsg.TelegramMsg
+
+	userService       service.UserService
+	auditService      service.AuditService
+	permissionService service.PermissionService
+	questionService   service.QuestionService
+	digestService     service.DigestService
+	slaService        service.SLAService
+	tagService        service.TagService
+	tagRuleService    service.TagRuleService
+	voteService       service.VoteService
+	faqService        service.FaqService
+	relayService      service.RelayService
+	outboxService     service.OutboxService
+	userRepo          repo.UserRepo
+	auditRepo         repo.AuditRepo
+	permissionRepo    repo.PermissionRepo
+	questionRepo      repo.QuestionRepo
+	digestRepo        repo.DigestRepo
+	tagRepo           repo.TagRepo
+	tagRuleRepo       repo.TagRuleRepo
+	voteRepo          repo.VoteRepo
+	faqRepo           repo.FaqRepo
+	threadRepo        repo.ThreadRepo
+	relayRepo         repo.RelayRepo
+	outboxRepo        repo.OutboxRepo
+	transactor        repo.Transactor
+
+	callbackUser     callback.CallbackUser
+	callbackAudit    callback.CallbackAudit
+	callbackQuestion callback.CallbackQuestion
+	callbackDigest   callback.CallbackDigest
+	callbackSLA      callback.CallbackSLA
+	callbackTag      callback.CallbackTag
+	callbackSearch   callback.CallbackSearch
+	callbackVote     callback.CallbackVote
+	callbackFaq      callback.CallbackFaq
+	callbackRelay    callback.CallbackRelay
+	callbackOutbox   callback.CallbackOutbox
+	viewGeneral      *view.ViewGeneral
+	metrics          *middleware.Metrics
 }
 
 func NewBot() *Bot {
@@ -49,24 +90,179 @@ func (b *Bot) initExcel() {
 }
 
 func (b *Bot) initHandler() {
-	b.viewGeneral = view.NewViewGeneral(b.log, b.tgMsg, b.psql)
+	dialogs, err := dialog.NewEngine(b.store, b.tgMsg, b.log, b.cfg.Store.DialogTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.dialogs = dialogs
 
-	callbackUser, err := callback.NewCallbackUser(b.userService, b.log, b.store, b.tgMsg, b.psql, b.excel)
+	// бот создается до обработчиков: после смены роли они обновляют меню команд пользователя
+	tgBot, err := tgbot.NewBot(b.bot, b.log, b.tgMsg, b.dialogs, b.userService, b.permissionService, b.questionService, b.faqService, b.relayService,
+		b.cfg.Telegram.AdminChatID, b.cfg.Update.Workers, b.cfg.Update.QueueSize, b.cfg.Update.ShutdownTimeout)
+	if err != nil {
+		b.log.Fatal("failed go create new bot: %v", err)
+	}
+	b.tgBot = tgBot
+
+	b.viewGeneral = view.NewViewGeneral(b.log, b.tgMsg, b.psql, b.permissionService)
+
+	callbackUser, err := callback.NewCallbackUser(b.userService, b.auditService, b.permissionService, b.tagService, b.log, b.dialogs, b.tgBot, b.tgMsg, b.psql, b.excel)
 	if err != nil {
 		log.Fatal(err)
 	}
 	b.callbackUser = callbackUser
 
+	callbackAudit, err := callback.NewCallbackAudit(b.auditService, b.log, b.tgMsg, b.excel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackAudit = callbackAudit
+
+	callbackQuestion, err := callback.NewCallbackQuestion(b.questionService, b.userService, b.log, b.dialogs, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackQuestion = callbackQuestion
+
+	callbackDigest, err := callback.NewCallbackDigest(b.digestService, b.log, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackDigest = callbackDigest
+
+	callbackSLA, err := callback.NewCallbackSLA(b.slaService, b.tagService, b.log, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackSLA = callbackSLA
+
+	callbackTag, err := callback.NewCallbackTag(b.tagService, b.tagRuleService, b.questionService, b.log, b.dialogs, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackTag = callbackTag
+
+	callbackSearch, err := callback.NewCallbackSearch(b.questionService, b.log, b.dialogs, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackSearch = callbackSearch
+
+	callbackVote, err := callback.NewCallbackVote(b.voteService, b.log, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackVote = callbackVote
+
+	callbackFaq, err := callback.NewCallbackFaq(b.faqService, b.log, b.dialogs, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackFaq = callbackFaq
+
+	callbackRelay, err := callback.NewCallbackRelay(b.relayService, b.questionService, b.log, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackRelay = callbackRelay
+
+	callbackOutbox, err := callback.NewCallbackOutbox(b.outboxService, b.log, b.tgMsg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b.callbackOutbox = callbackOutbox
+
+	b.dialogs.Register(b.callbackUser.AdminCreateDialog())
+	b.dialogs.Register(b.callbackUser.AdminDeleteDialog())
+	b.dialogs.Register(b.callbackQuestion.AnswerDialog())
+	b.dialogs.Register(b.callbackTag.TagDialog())
+	b.dialogs.Register(b.callbackTag.RuleDialog())
+	b.dialogs.Register(b.callbackSearch.SearchDialog())
+	b.dialogs.Register(b.callbackFaq.FaqDialog())
+
+	b.metrics = middleware.NewMetrics(b.log)
+
 	b.log.Info("Initializing handler")
 }
 
 func (b *Bot) initUsecase() {
-	userService, err := service.NewUserService(b.userRepo, b.log, b.tgMsg, b.psql)
+	auditService, err := service.NewAuditService(b.auditRepo, b.log)
+	if err != nil {
+		b.log.Fatal("Failed to initialize audit service")
+	}
+	b.auditService = auditService
+
+	permissionService, err := service.NewPermissionService(b.permissionRepo, b.log)
+	if err != nil {
+		b.log.Fatal("Failed to initialize permission service")
+	}
+	b.permissionService = permissionService
+
+	userService, err := service.NewUserService(b.userRepo, b.auditService, b.log, b.tgMsg, b.psql)
 	if err != nil {
 		b.log.Fatal("Failed to initialize user service")
 	}
 	b.userService = userService
 
+	tagService, err := service.NewTagService(b.tagRepo, b.tagRuleRepo, b.auditService, b.log)
+	if err != nil {
+		b.log.Fatal("Failed to initialize tag service")
+	}
+	b.tagService = tagService
+
+	tagRuleService, err := service.NewTagRuleService(b.tagRuleRepo, b.tagRepo, b.auditService, b.log,
+		b.cfg.Question.AutoTagConfidence)
+	if err != nil {
+		b.log.Fatal("Failed to initialize tag rule service")
+	}
+	b.tagRuleService = tagRuleService
+
+	outboxService, err := service.NewOutboxService(b.outboxRepo, b.threadRepo, b.auditService, b.log, b.tgMsg,
+		b.cfg.Outbox.Interval, b.cfg.Outbox.MaxAttempts)
+	if err != nil {
+		b.log.Fatal("Failed to initialize outbox service")
+	}
+	b.outboxService = outboxService
+
+	questionService, err := service.NewQuestionService(b.questionRepo, b.userRepo, b.tagRepo, b.threadRepo, b.transactor, b.tagRuleService, b.auditService, b.outboxService, b.permissionService, b.log, b.tgMsg,
+		b.cfg.Telegram.AdminChatID, b.cfg.Question.ClaimTTL, b.cfg.Question.DuplicateThreshold, b.cfg.Question.DuplicateWindow)
+	if err != nil {
+		b.log.Fatal("Failed to initialize question service")
+	}
+	b.questionService = questionService
+
+	voteService, err := service.NewVoteService(b.voteRepo, b.questionRepo, b.userRepo, b.log)
+	if err != nil {
+		b.log.Fatal("Failed to initialize vote service")
+	}
+	b.voteService = voteService
+
+	faqService, err := service.NewFaqService(b.faqRepo, b.userRepo, b.questionService, b.auditService, b.log, b.tgMsg)
+	if err != nil {
+		b.log.Fatal("Failed to initialize faq service")
+	}
+	b.faqService = faqService
+
+	relayService, err := service.NewRelayService(b.relayRepo, b.userRepo, b.permissionService, b.auditService, b.outboxService, b.log, b.tgMsg,
+		b.cfg.Telegram.RelayChatID)
+	if err != nil {
+		b.log.Fatal("Failed to initialize relay service")
+	}
+	b.relayService = relayService
+
+	digestService, err := service.NewDigestService(b.digestRepo, b.userService, b.questionService, b.outboxService, b.transactor, b.log,
+		b.cfg.Digest.SendAt, b.cfg.Digest.WeeklyDay)
+	if err != nil {
+		b.log.Fatal("Failed to initialize digest service")
+	}
+	b.digestService = digestService
+
+	slaService, err := service.NewSLAService(b.questionRepo, b.transactor, b.outboxService, b.log, b.cfg.Telegram.AdminChatID, b.cfg.Question.SLA)
+	if err != nil {
+		b.log.Fatal("Failed to initialize sla service")
+	}
+	b.slaService = slaService
+
 	b.log.Info("Initializing usecase")
 }
 
@@ -78,6 +274,90 @@ func (b *Bot) initRepo() {
 
 	b.userRepo = userRepo
 
+	auditRepo, err := repo.NewAuditRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize audit repo")
+	}
+
+	b.auditRepo = auditRepo
+
+	permissionRepo, err := repo.NewPermissionRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize permission repo")
+	}
+
+	b.permissionRepo = permissionRepo
+
+	questionRepo, err := repo.NewQuestionRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize question repo")
+	}
+
+	b.questionRepo = questionRepo
+
+	digestRepo, err := repo.NewDigestRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize digest repo")
+	}
+
+	b.digestRepo = digestRepo
+
+	tagRepo, err := repo.NewTagRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize tag repo")
+	}
+
+	b.tagRepo = tagRepo
+
+	tagRuleRepo, err := repo.NewTagRuleRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize tag rule repo")
+	}
+
+	b.tagRuleRepo = tagRuleRepo
+
+	voteRepo, err := repo.NewVoteRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize vote repo")
+	}
+
+	b.voteRepo = voteRepo
+
+	faqRepo, err := repo.NewFaqRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize faq repo")
+	}
+
+	b.faqRepo = faqRepo
+
+	threadRepo, err := repo.NewThreadRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize thread repo")
+	}
+
+	b.threadRepo = threadRepo
+
+	relayRepo, err := repo.NewRelayRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize relay repo")
+	}
+
+	b.relayRepo = relayRepo
+
+	outboxRepo, err := repo.NewOutboxRepo(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize outbox repo")
+	}
+
+	b.outboxRepo = outboxRepo
+
+	transactor, err := repo.NewTransactor(b.psql)
+	if err != nil {
+		log.Fatal("Failed to initialize transactor")
+	}
+
+	b.transactor = transactor
+
 	b.log.Info("Initializing repo")
 }
 
@@ -113,16 +393,47 @@ func (b *Bot) initLogger() {
 	b.log.Info("Initializing logger")
 }
 
-func (b *Bot) initStore() {
-	b.store = store.NewStore()
-
-	b.log.Info("Initializing store")
-}
-
-func (b *Bot) initCallbackStorage() {
-	b.callbackStore = store.NewCallbackStorage()
+func (b *Bot) initStore(ctx context.Context) {
+	switch b.cfg.Store.Backend {
+	case config.StoreMemory:
+		b.store = store.NewStore(b.cfg.Store.TTL)
+		b.log.Info("Callback payloads are kept in memory, long buttons expire on restart")
+	case config.StoreRedis:
+		options, err := redis.ParseURL(b.cfg.Store.RedisURL)
+		if err != nil {
+			b.log.Fatal("failed to parse REDIS_URL: %v", err)
+		}
+		b.redis = redis.NewClient(options)
+		if err := b.redis.Ping(ctx).Err(); err != nil {
+			b.log.Fatal("failed to connect Redis: %v", err)
+		}
+
+		redisStore, err := store.NewRedisStore(b.redis, b.log, b.cfg.Store.TTL)
+		if err != nil {
+			b.log.Fatal("failed to initialize redis store: %v", err)
+		}
+		b.store = redisStore
+
+		payloads, err := store.NewRedisPayloads(b.redis, b.log)
+		if err != nil {
+			b.log.Fatal("failed to initialize redis payloads: %v", err)
+		}
+		action.Payloads = payloads
+	default:
+		postgresStore, err := store.NewPostgresStore(b.psql, b.log, b.cfg.Store.TTL)
+		if err != nil {
+			b.log.Fatal("failed to initialize postgres store: %v", err)
+		}
+		b.store = postgresStore
+
+		payloads, err := store.NewPostgresPayloads(b.psql, b.log)
+		if err != nil {
+			b.log.Fatal("failed to initialize postgres payloads: %v", err)
+		}
+		action.Payloads = payloads
+	}
 
-	b.log.Info("Initializing callback storage")
+	b.log.Info("Initializing %s store", b.cfg.Store.Backend)
 }
 
 func (b *Bot) initTelegramBot() {
@@ -142,9 +453,8 @@ func (b *Bot) initialize(ctx context.Context) {
 	b.initExcel()
 	b.initConfig()
 	b.initTelegramBot()
-	b.initStore()
-	b.initCallbackStorage()
 	b.initPostgres(ctx)
+	b.initStore(ctx)
 	b.initMessage()
 	b.initRepo()
 	b.initUsecase()
@@ -155,25 +465,158 @@ func (b *Bot) initialize(ctx context.Context) {
 func (b *Bot) Run(ctx context.Context) {
 	startBot := time.Now()
 	b.initialize(ctx)
-	newBot, err := tgbot.NewBot(b.bot, b.log, b.store, b.tgMsg, b.userService, b.callbackStore)
-	if err != nil {
-		b.log.Fatal("failed go create new bot: ", err)
-	}
+	newBot := b.tgBot
 	defer b.psql.Close()
+	defer b.dialogs.Close()
+	if b.redis != nil {
+		defer b.redis.Close()
+	}
 
-	newBot.RegisterCommandView("start", b.viewGeneral.CallbackStartUser())
-
-	newBot.RegisterCommandView("admin", middleware.AdminMiddleware(b.userService, b.viewGeneral.CallbackStartAdminPanel()))
+	if webhook := b.cfg.Webhook; webhook.Enabled {
+		newBot.UseWebhook(tgbot.WebhookConfig{
+			Listen:   webhook.Listen,
+			Path:     webhook.Path,
+			URL:      webhook.URL,
+			Secret:   webhook.Secret,
+			CertFile: webhook.CertFile,
+			KeyFile:  webhook.KeyFile,
+		})
+	}
 
-	newBot.RegisterCommandCallback("bot_setting", middleware.AdminMiddleware(b.userService, b.callbackUser.QuestionSettings()))
-	newBot.RegisterCommandCallback("main_menu", middleware.AdminMiddleware(b.userService, b.callbackUser.MainMenu()))
-	newBot.RegisterCommandCallback("user_setting", middleware.AdminMiddleware(b.userService, b.callbackUser.AdminRoleSetting()))
-	newBot.RegisterCommandCallback("admin_look_up", middleware.AdminMiddleware(b.userService, b.callbackUser.AdminLookUp()))
-	newBot.RegisterCommandCallback("admin_delete_role", middleware.AdminMiddleware(b.userService, b.callbackUser.AdminDeleteRole()))
-	newBot.RegisterCommandCallback("admin_set_role", middleware.AdminMiddleware(b.userService, b.callbackUser.AdminSetRole()))
+	// в чате администраторов и группе поддержки меню показывает все команды, права проверяются при вызове
+	newBot.ScopeCommands(b.cfg.Telegram.AdminChatID, b.cfg.Telegram.RelayChatID)
+
+	// Recovery после Logging и Metrics: паника обработчика попадает в них как ошибка
+	newBot.Use(
+		middleware.Logging(b.log),
+		b.metrics.Middleware(),
+		middleware.Recovery(b.log),
+		middleware.RateLimit(b.cfg.Update.RateLimit, b.tgMsg, b.log),
+	)
+
+	panelAccess := newBot.Group(middleware.Permission(b.permissionService, entity.PermPanelAccess))
+	questionRead := newBot.Group(middleware.Permission(b.permissionService, entity.PermQuestionRead))
+	questionExport := newBot.Group(middleware.Permission(b.permissionService, entity.PermQuestionExport))
+	roleManage := newBot.Group(middleware.Permission(b.permissionService, entity.PermRoleManage))
+	questionAnswer := newBot.Group(middleware.Permission(b.permissionService, entity.PermQuestionAnswer))
+	userBan := newBot.Group(middleware.Permission(b.permissionService, entity.PermUserBan))
+	questionPublish := newBot.Group(middleware.Permission(b.permissionService, entity.PermQuestionPublish))
+	tagManage := newBot.Group(middleware.Permission(b.permissionService, entity.PermTagManage))
+	faqManage := newBot.Group(middleware.Permission(b.permissionService, entity.PermFaqManage))
+	outboxManage := newBot.Group(middleware.Permission(b.permissionService, entity.PermOutboxManage))
+	auditRead := newBot.Group(middleware.Permission(b.permissionService, entity.PermAuditRead))
+
+	newBot.RegisterCommandView(tgbot.Command{Name: "start", Description: "Начать работу с ботом"}, b.viewGeneral.CallbackStartUser())
+	newBot.RegisterCommandView(tgbot.Command{Name: "help", Description: "Список команд"}, newBot.HelpView())
+	newBot.RegisterMessageView(newBot.QuestionView())
+
+	newBot.RegisterCommandView(tgbot.Command{Name: "cancel", Description: "Отменить текущее действие"}, b.dialogs.Cancel())
+	newBot.RegisterCommandView(tgbot.Command{Name: "back", Description: "Вернуться к предыдущему шагу"}, b.dialogs.Back())
+	newBot.RegisterCommandCallback("dialog_cancel", b.dialogs.Cancel())
+	newBot.RegisterCommandCallback("dialog_back", b.dialogs.Back())
+
+	newBot.RegisterCommandView(tgbot.Command{Name: "vote", Description: "Проголосовать за вопросы читателей"}, b.callbackVote.VoteView())
+	newBot.RegisterCommandCallback("vote_up", b.callbackVote.VoteUp())
+	newBot.RegisterCommandCallback("vote_more", b.callbackVote.VoteMore())
+
+	panelAccess.RegisterCommandView(tgbot.Command{Name: "admin", Description: "Панель администратора", Permission: entity.PermPanelAccess},
+		b.viewGeneral.CallbackStartAdminPanel())
+
+	questionRead.RegisterCommandView(tgbot.Command{Name: "search", Description: "Поиск по вопросам", Permission: entity.PermQuestionRead},
+		b.callbackSearch.SearchCommand())
+	questionRead.RegisterCommandCallback("search_start", b.callbackSearch.SearchStart())
+	questionRead.RegisterCommandCallback("search_page", b.callbackSearch.SearchPage())
+
+	panelAccess.RegisterCommandCallback("main_menu", b.callbackUser.MainMenu())
+	questionExport.RegisterCommandCallback("bot_setting", b.callbackUser.QuestionSettings())
+	questionExport.RegisterCommandCallback("q_export", b.callbackUser.QuestionExport())
+
+	roleManage.RegisterCommandCallback("user_setting", b.callbackUser.AdminRoleSetting())
+	roleManage.RegisterCommandCallback("admin_look_up", b.callbackUser.AdminLookUp())
+	roleManage.RegisterCommandCallback("admin_delete_role", b.callbackUser.AdminDeleteRole())
+	roleManage.RegisterCommandCallback("admin_set_role", b.callbackUser.AdminSetRole())
+	roleManage.RegisterCommandCallback("role_pick", b.callbackUser.AdminPickRole())
+
+	questionAnswer.RegisterCommandCallback("q_check", b.callbackQuestion.QuestionCheck())
+	questionAnswer.RegisterCommandCallback("q_answer", b.callbackQuestion.QuestionAnswer())
+	questionAnswer.RegisterCommandCallback("q_reject", b.callbackQuestion.QuestionReject())
+	userBan.RegisterCommandCallback("q_ban", b.callbackQuestion.QuestionBan())
+	questionPublish.RegisterCommandCallback("q_publish", b.callbackQuestion.QuestionPublish())
+	questionAnswer.RegisterCommandCallback("q_claim", b.callbackQuestion.QuestionClaim())
+	questionAnswer.RegisterCommandCallback("q_release", b.callbackQuestion.QuestionRelease())
+	questionAnswer.RegisterCommandCallback("q_delegate", b.callbackQuestion.QuestionDelegate())
+	questionAnswer.RegisterCommandCallback("q_handto", b.callbackQuestion.QuestionHandTo())
+	questionRead.RegisterCommandCallback("q_similar", b.callbackQuestion.QuestionSimilar())
+	questionAnswer.RegisterCommandCallback("q_merge", b.callbackQuestion.QuestionMerge())
+	questionAnswer.RegisterCommandCallback("q_detach", b.callbackQuestion.QuestionDetach())
+	questionRead.RegisterCommandCallback("q_card", b.callbackQuestion.QuestionCard())
+	questionAnswer.RegisterCommandCallback("my_questions", b.callbackQuestion.MyQuestions())
+	questionRead.RegisterCommandCallback("q_list", b.callbackTag.QuestionFilterList())
+	questionRead.RegisterCommandCallback("q_top", b.callbackTag.QuestionFilterList())
+	questionRead.RegisterCommandCallback("q_tags", b.callbackTag.QuestionTags())
+	questionRead.RegisterCommandCallback("qtag", b.callbackTag.QuestionTagToggle())
+	questionAnswer.RegisterReplyView(b.callbackQuestion.ReplyAnswer())
+
+	questionRead.RegisterCommandCallback("sla_stats", b.callbackSLA.SLAStats())
+
+	tagManage.RegisterCommandCallback("tag_setting", b.callbackTag.TagSetting())
+	tagManage.RegisterCommandCallback("tag_create", b.callbackTag.TagCreate())
+	tagManage.RegisterCommandCallback("tag_delete", b.callbackTag.TagDelete())
+
+	tagManage.RegisterCommandCallback("rule_list", b.callbackTag.RuleList())
+	tagManage.RegisterCommandCallback("rule_create", b.callbackTag.RuleCreate())
+	tagManage.RegisterCommandCallback("rule_delete", b.callbackTag.RuleDelete())
+
+	faqManage.RegisterCommandCallback("faq_setting", b.callbackFaq.FaqSetting())
+	faqManage.RegisterCommandCallback("faq_create", b.callbackFaq.FaqCreate())
+	faqManage.RegisterCommandCallback("faq_delete", b.callbackFaq.FaqDelete())
+	questionAnswer.RegisterCommandCallback("q_relay", b.callbackRelay.QuestionRelay())
+	questionAnswer.RegisterCommandView(tgbot.Command{Name: "close", Description: "Закрыть переписку в теме", Permission: entity.PermQuestionAnswer},
+		b.callbackRelay.RelayClose())
+	questionAnswer.RegisterCommandView(tgbot.Command{Name: "reopen", Description: "Возобновить переписку в теме", Permission: entity.PermQuestionAnswer},
+		b.callbackRelay.RelayReopen())
+
+	newBot.RegisterCommandCallback("faq_helped", b.callbackFaq.FaqHelped())
+	newBot.RegisterCommandCallback("faq_send", b.callbackFaq.FaqSend())
+
+	panelAccess.RegisterCommandCallback("digest_setting", b.callbackDigest.DigestSetting())
+	panelAccess.RegisterCommandCallback("digest_toggle", b.callbackDigest.DigestToggle())
+	panelAccess.RegisterCommandCallback("digest_preview", b.callbackDigest.DigestPreview())
+
+	outboxManage.RegisterCommandCallback("outbox_list", b.callbackOutbox.OutboxList())
+	outboxManage.RegisterCommandCallback("outbox_retry", b.callbackOutbox.OutboxRetry())
+	outboxManage.RegisterCommandCallback("outbox_requeue", b.callbackOutbox.OutboxRequeue())
+
+	auditRead.RegisterCommandCallback("audit_log", b.callbackAudit.AuditLog())
+	auditRead.RegisterCommandCallback("audit_page", b.callbackAudit.AuditPage())
+	auditRead.RegisterCommandCallback("audit_export", b.callbackAudit.AuditExport())
+
+	// фоновые циклы останавливаются и при ошибке приема обновлений
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// фоновые циклы должны завершиться до закрытия пула соединений с Postgres
+	var background sync.WaitGroup
+	for _, run := range []func(context.Context){
+		b.digestService.Run,
+		b.questionService.RunClaimRelease,
+		b.slaService.Run,
+		b.outboxService.Run,
+		b.metrics.Run,
+	} {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(ctx)
+		}()
+	}
 
 	b.log.Info("Initialize bot took [%f] seconds", time.Since(startBot).Seconds())
 	if err := newBot.Run(ctx); err != nil {
-		b.log.Fatal("failed to run Telegram Bot: %v", err)
+		b.log.Error("failed to run Telegram Bot: %v", err)
 	}
+	cancel()
+
+	background.Wait()
+	b.log.Info("Bot stopped, closing PostgreSQL")
 }
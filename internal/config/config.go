@@ -1,14 +1,24 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type (
 	Config struct {
 		Postgres Postgres `json:"postgres"`
 		Telegram Telegram `json:"telegram"`
+		Digest   Digest   `json:"digest"`
+		Question Question `json:"question"`
+		Update   Update   `json:"update"`
+		Webhook  Webhook  `json:"webhook"`
+		Outbox   Outbox   `json:"outbox"`
+		Store    Store    `json:"store"`
 	}
 
 	Postgres struct {
@@ -16,8 +26,77 @@ type (
 	}
 
 	Telegram struct {
-		Token string `json:"token"`
+		Token       string `json:"token"`
+		AdminChatID int64  `json:"admin_chat_id"`
+		// RelayChatID - форум-супергруппа для переписки с пользователями, 0 отключает переписку
+		RelayChatID int64 `json:"relay_chat_id"`
 	}
+
+	Digest struct {
+		// SendAt - время отправки от начала суток, отрицательное значение отключает дайджест
+		SendAt    time.Duration `json:"send_at"`
+		WeeklyDay time.Weekday  `json:"weekly_day"`
+	}
+
+	Update struct {
+		// Workers - сколько чатов обрабатывается параллельно, обновления одного чата идут по порядку
+		Workers int `json:"workers"`
+		// QueueSize - очередь обновлений одного обработчика, при заполнении прием обновлений ждет
+		QueueSize int `json:"queue_size"`
+		// ShutdownTimeout - сколько при остановке ждать завершения обработки принятых обновлений
+		ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+		// RateLimit - сколько обновлений в минуту принимается от одного пользователя, 0 - без ограничения
+		RateLimit int `json:"rate_limit"`
+	}
+
+	Webhook struct {
+		// Enabled - UPDATE_MODE=webhook, иначе обновления получаются через long polling
+		Enabled  bool   `json:"enabled"`
+		Listen   string `json:"listen"`
+		Path     string `json:"path"`
+		URL      string `json:"url"`
+		Secret   string `json:"-"`
+		CertFile string `json:"cert_file"`
+		KeyFile  string `json:"key_file"`
+	}
+
+	Outbox struct {
+		// Interval - как часто проверять очередь, если новых сообщений не поступало
+		Interval time.Duration `json:"interval"`
+		// MaxAttempts - после стольких неудачных попыток сообщение считается недоставленным
+		MaxAttempts int `json:"max_attempts"`
+	}
+
+	Store struct {
+		// Backend - где хранится состояние диалогов: memory, postgres или redis
+		Backend StoreBackend `json:"backend"`
+		// TTL - через сколько незавершенный диалог забывается
+		TTL time.Duration `json:"ttl"`
+		// DialogTimeout - через сколько бездействия диалог отменяется с сообщением пользователю
+		DialogTimeout time.Duration `json:"dialog_timeout"`
+		RedisURL      string        `json:"-"`
+	}
+
+	Question struct {
+		// ClaimTTL - через сколько закрепление вопроса за администратором снимается автоматически
+		ClaimTTL time.Duration `json:"claim_ttl"`
+		// SLA - время, после которого неотвеченный вопрос считается просроченным, 0 отключает оповещения
+		SLA time.Duration `json:"sla"`
+		// AutoTagConfidence - минимальная уверенность правила, при которой тег ставится автоматически
+		AutoTagConfidence float64 `json:"auto_tag_confidence"`
+		// DuplicateThreshold - сходство текстов, начиная с которого новый вопрос считается дубликатом открытого
+		DuplicateThreshold float64 `json:"duplicate_threshold"`
+		// DuplicateWindow - насколько старые вопросы сравниваются с новым
+		DuplicateWindow time.Duration `json:"duplicate_window"`
+	}
+)
+
+type StoreBackend string
+
+const (
+	StoreMemory   StoreBackend = "memory"
+	StorePostgres StoreBackend = "postgres"
+	StoreRedis    StoreBackend = "redis"
 )
 
 func New() (*Config, error) {
@@ -26,14 +105,244 @@ func New() (*Config, error) {
 		return nil, err
 	}
 
+	adminChatID, err := parseInt64(os.Getenv("ADMIN_CHAT_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("ADMIN_CHAT_ID: %w", err)
+	}
+
+	relayChatID, err := parseInt64(os.Getenv("RELAY_CHAT_ID"))
+	if err != nil {
+		return nil, fmt.Errorf("RELAY_CHAT_ID: %w", err)
+	}
+
+	digestSendAt, err := parseClock(os.Getenv("DIGEST_TIME"))
+	if err != nil {
+		return nil, fmt.Errorf("DIGEST_TIME: %w", err)
+	}
+
+	digestWeeklyDay, err := parseWeekday(os.Getenv("DIGEST_WEEKLY_DAY"))
+	if err != nil {
+		return nil, fmt.Errorf("DIGEST_WEEKLY_DAY: %w", err)
+	}
+
+	claimTTL, err := parseDuration(os.Getenv("CLAIM_TTL"), 4*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("CLAIM_TTL: %w", err)
+	}
+
+	sla, err := parseDuration(os.Getenv("SLA_TIMEOUT"), 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("SLA_TIMEOUT: %w", err)
+	}
+
+	autoTagConfidence, err := parseFloat(os.Getenv("AUTO_TAG_MIN_CONFIDENCE"), 0.5)
+	if err != nil {
+		return nil, fmt.Errorf("AUTO_TAG_MIN_CONFIDENCE: %w", err)
+	}
+
+	duplicateThreshold, err := parseFloat(os.Getenv("DUPLICATE_THRESHOLD"), 0.6)
+	if err != nil {
+		return nil, fmt.Errorf("DUPLICATE_THRESHOLD: %w", err)
+	}
+
+	duplicateWindow, err := parseDuration(os.Getenv("DUPLICATE_WINDOW"), 30*24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("DUPLICATE_WINDOW: %w", err)
+	}
+
+	updateWorkers, err := parseInt(os.Getenv("UPDATE_WORKERS"), 8)
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE_WORKERS: %w", err)
+	}
+
+	updateQueueSize, err := parseInt(os.Getenv("UPDATE_QUEUE_SIZE"), 100)
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE_QUEUE_SIZE: %w", err)
+	}
+
+	updateRateLimit, err := parseInt(os.Getenv("UPDATE_RATE_LIMIT"), 30)
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE_RATE_LIMIT: %w", err)
+	}
+
+	shutdownTimeout, err := parseDuration(os.Getenv("SHUTDOWN_TIMEOUT"), 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT: %w", err)
+	}
+
+	webhookEnabled, err := parseUpdateMode(os.Getenv("UPDATE_MODE"))
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE_MODE: %w", err)
+	}
+	// без секрета любой, кто достучится до порта, сможет отправить обновление от имени администратора
+	if webhookEnabled && os.Getenv("WEBHOOK_SECRET") == "" {
+		return nil, fmt.Errorf("WEBHOOK_SECRET is required for webhook update mode")
+	}
+
+	outboxInterval, err := parseDuration(os.Getenv("OUTBOX_INTERVAL"), 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("OUTBOX_INTERVAL: %w", err)
+	}
+
+	outboxMaxAttempts, err := parseInt(os.Getenv("OUTBOX_MAX_ATTEMPTS"), 8)
+	if err != nil {
+		return nil, fmt.Errorf("OUTBOX_MAX_ATTEMPTS: %w", err)
+	}
+
+	storeBackend, err := parseStoreBackend(os.Getenv("STORE_BACKEND"))
+	if err != nil {
+		return nil, fmt.Errorf("STORE_BACKEND: %w", err)
+	}
+	if storeBackend == StoreRedis && os.Getenv("REDIS_URL") == "" {
+		return nil, fmt.Errorf("REDIS_URL is required for redis store backend")
+	}
+
+	storeTTL, err := parseDuration(os.Getenv("STORE_TTL"), 24*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("STORE_TTL: %w", err)
+	}
+
+	dialogTimeout, err := parseDuration(os.Getenv("DIALOG_TIMEOUT"), 15*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("DIALOG_TIMEOUT: %w", err)
+	}
+
 	config := &Config{
 		Postgres: Postgres{
 			URL: os.Getenv("POSTGRES_URL"),
 		},
 		Telegram: Telegram{
-			Token: os.Getenv("TOKEN_TG"),
+			Token:       os.Getenv("TOKEN_TG"),
+			AdminChatID: adminChatID,
+			RelayChatID: relayChatID,
+		},
+		Digest: Digest{
+			SendAt:    digestSendAt,
+			WeeklyDay: digestWeeklyDay,
+		},
+		Question: Question{
+			ClaimTTL: claimTTL,
+			SLA:      sla,
+
+			AutoTagConfidence:  autoTagConfidence,
+			DuplicateThreshold: duplicateThreshold,
+			DuplicateWindow:    duplicateWindow,
+		},
+		Update: Update{
+			Workers:         updateWorkers,
+			QueueSize:       updateQueueSize,
+			ShutdownTimeout: shutdownTimeout,
+			RateLimit:       updateRateLimit,
+		},
+		Webhook: Webhook{
+			Enabled:  webhookEnabled,
+			Listen:   withDefault(os.Getenv("WEBHOOK_LISTEN"), ":8443"),
+			Path:     withDefault(os.Getenv("WEBHOOK_PATH"), "/webhook"),
+			URL:      os.Getenv("WEBHOOK_URL"),
+			Secret:   os.Getenv("WEBHOOK_SECRET"),
+			CertFile: os.Getenv("WEBHOOK_TLS_CERT"),
+			KeyFile:  os.Getenv("WEBHOOK_TLS_KEY"),
+		},
+		Outbox: Outbox{
+			Interval:    outboxInterval,
+			MaxAttempts: outboxMaxAttempts,
+		},
+		Store: Store{
+			Backend:       storeBackend,
+			TTL:           storeTTL,
+			DialogTimeout: dialogTimeout,
+			RedisURL:      os.Getenv("REDIS_URL"),
 		},
 	}
 
 	return config, nil
 }
+
+// parseInt64 - пустое значение означает, что параметр не задан
+func parseInt64(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// parseClock - "15:04" в смещение от начала суток. Пустое значение отключает функцию
+func parseClock(value string) (time.Duration, error) {
+	if value == "" {
+		return -1, nil
+	}
+
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// parseWeekday - номер дня (0 - воскресенье) или английское название, по умолчанию понедельник
+func parseWeekday(value string) (time.Weekday, error) {
+	if value == "" {
+		return time.Monday, nil
+	}
+
+	if day, err := strconv.Atoi(value); err == nil && day >= 0 && day <= 6 {
+		return time.Weekday(day), nil
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), value) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", value)
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseUpdateMode - true для вебхука, по умолчанию long polling
+func parseUpdateMode(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "polling":
+		return false, nil
+	case "webhook":
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown update mode %q", value)
+}
+
+// parseStoreBackend - по умолчанию состояние хранится в Postgres и переживает перезапуск
+func parseStoreBackend(value string) (StoreBackend, error) {
+	switch backend := StoreBackend(strings.ToLower(value)); backend {
+	case "":
+		return StorePostgres, nil
+	case StoreMemory, StorePostgres, StoreRedis:
+		return backend, nil
+	}
+	return "", fmt.Errorf("unknown store backend %q", value)
+}
+
+func withDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func parseInt(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func parseFloat(value string, defaultValue float64) (float64, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
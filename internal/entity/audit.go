@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditRoleChange      AuditAction = "role_change"
+	AuditQuestionExport  AuditAction = "question_export"
+	AuditAuditExport     AuditAction = "audit_export"
+	AuditUserBan         AuditAction = "user_ban"
+	AuditDelete          AuditAction = "delete"
+	AuditAnswer          AuditAction = "answer"
+	AuditQuestionCheck   AuditAction = "question_check"
+	AuditQuestionReject  AuditAction = "question_reject"
+	AuditQuestionAssign  AuditAction = "question_assign"
+	AuditQuestionPublish AuditAction = "question_publish"
+	AuditQuestionMerge   AuditAction = "question_merge"
+	AuditTagCreate       AuditAction = "tag_create"
+	AuditTagRuleCreate   AuditAction = "tag_rule_create"
+	AuditFaqCreate       AuditAction = "faq_create"
+	AuditRelayOpen       AuditAction = "relay_open"
+	AuditRelayClose      AuditAction = "relay_close"
+	AuditOutboxRetry     AuditAction = "outbox_retry"
+)
+
+// AuditChange - значение поля до и после изменения
+type AuditChange struct {
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// AuditDiff - набор изменений по полям, сохраняется в audit_log.diff
+type AuditDiff map[string]AuditChange
+
+type AuditLog struct {
+	ID            int64       `json:"id"`
+	ActorID       int64       `json:"actor_id"`
+	ActorUsername string      `json:"actor_username,omitempty"`
+	Action        AuditAction `json:"action"`
+	Target        string      `json:"target"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Diff          AuditDiff   `json:"diff,omitempty"`
+}
+
+func (a AuditLog) String() string {
+	return fmt.Sprintf("(id: %d | actor: %d | action: %s | target: %s | created_at: %v)",
+		a.ID, a.ActorID, a.Action, a.Target, a.CreatedAt)
+}
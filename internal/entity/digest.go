@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"github.com/Enthreeka/tg-question-bot/pkg/text"
+	"time"
+)
+
+type DigestKind string
+
+const (
+	DigestDaily  DigestKind = "daily"
+	DigestWeekly DigestKind = "weekly"
+)
+
+var DigestKinds = []DigestKind{DigestDaily, DigestWeekly}
+
+func (k DigestKind) Title() string {
+	switch k {
+	case DigestDaily:
+		return "Ежедневный дайджест"
+	case DigestWeekly:
+		return "Еженедельный дайджест"
+	}
+	return string(k)
+}
+
+// Period - интервал, за который собирается первый дайджест, если предыдущего не было
+func (k DigestKind) Period() time.Duration {
+	if k == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func (k DigestKind) IsValid() bool {
+	return k == DigestDaily || k == DigestWeekly
+}
+
+type Digest struct {
+	Kind         DigestKind
+	From         time.Time
+	To           time.Time
+	NewQuestions int
+	Unanswered   int
+	NewUsers     map[string]int
+	Keywords     []text.Keyword
+}
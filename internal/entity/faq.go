@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Faq - готовый ответ, который предлагается пользователю до отправки вопроса
+type Faq struct {
+	ID        int       `json:"id"`
+	Patterns  []string  `json:"patterns"`
+	Answer    string    `json:"answer"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (f Faq) String() string {
+	return fmt.Sprintf("(id: %d | patterns: %v | created_by: %d)", f.ID, f.Patterns, f.CreatedBy)
+}
+
+type FaqOutcome string
+
+const (
+	// FaqHelped - пользователь нашел ответ в подсказке
+	FaqHelped FaqOutcome = "helped"
+	// FaqSent - пользователь все равно отправил вопрос
+	FaqSent FaqOutcome = "sent"
+)
+
+// FaqSuggestion - показ подсказки, вопрос хранится до решения пользователя
+type FaqSuggestion struct {
+	ID        int        `json:"id"`
+	FaqID     int        `json:"faq_id"`
+	UserID    int64      `json:"user_id"`
+	Question  string     `json:"question"`
+	Outcome   FaqOutcome `json:"outcome,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type FaqStats struct {
+	FaqID  int `json:"faq_id"`
+	Shown  int `json:"shown"`
+	Helped int `json:"helped"`
+	Sent   int `json:"sent"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	// OutboxDead - попытки доставки исчерпаны или Telegram отказал окончательно
+	OutboxDead OutboxStatus = "dead"
+)
+
+// OutboxMessage - сообщение пользователю, сохраненное вместе с изменением, которое его вызвало.
+// Markup - клавиатура в JSON, QuestionID - вопрос, к которому привязывается отправленное сообщение
+type OutboxMessage struct {
+	ID            int64        `json:"id"`
+	ChatID        int64        `json:"chat_id"`
+	Text          string       `json:"text"`
+	Markup        []byte       `json:"markup,omitempty"`
+	QuestionID    int          `json:"question_id,omitempty"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+}
+
+func (o OutboxMessage) String() string {
+	return fmt.Sprintf("(id: %d | chat_id: %d | status: %s | attempts: %d)",
+		o.ID, o.ChatID, o.Status, o.Attempts)
+}
+
+type OutboxStats struct {
+	Pending int `json:"pending"`
+	Dead    int `json:"dead"`
+}
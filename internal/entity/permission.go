@@ -0,0 +1,38 @@
+package entity
+
+type Permission string
+
+const (
+	PermPanelAccess     Permission = "panel.access"
+	PermQuestionRead    Permission = "question.read"
+	PermQuestionAnswer  Permission = "question.answer"
+	PermQuestionExport  Permission = "question.export"
+	PermQuestionPublish Permission = "question.publish"
+	PermUserBan         Permission = "user.ban"
+	PermRoleManage      Permission = "role.manage"
+	PermAuditRead       Permission = "audit.read"
+	PermTagManage       Permission = "tag.manage"
+	PermFaqManage       Permission = "faq.manage"
+	PermOutboxManage    Permission = "outbox.manage"
+)
+
+// PermissionSet - набор прав пользователя, вычисленный по его роли
+type PermissionSet map[Permission]struct{}
+
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, p := range permissions {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+func (p PermissionSet) Has(permission Permission) bool {
+	_, ok := p[permission]
+	return ok
+}
+
+// Allowed - используется при построении клавиатур, где права передаются строкой
+func (p PermissionSet) Allowed(permission string) bool {
+	return p.Has(Permission(permission))
+}
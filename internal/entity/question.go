@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type QuestionStatus string
+
+const (
+	QuestionNew      QuestionStatus = "new"
+	QuestionChecked  QuestionStatus = "checked"
+	QuestionAnswered QuestionStatus = "answered"
+	QuestionRejected QuestionStatus = "rejected"
+)
+
+func (s QuestionStatus) Title() string {
+	switch s {
+	case QuestionNew:
+		return "Новый"
+	case QuestionChecked:
+		return "Проверен"
+	case QuestionAnswered:
+		return "Отвечен"
+	case QuestionRejected:
+		return "Отклонен"
+	}
+	return string(s)
+}
+
+type Question struct {
+	ID              int            `json:"id"`
+	UserID          int64          `json:"user_id"`
+	Question        string         `json:"question"`
+	IsChecked       bool           `json:"is_checked"`
+	Status          QuestionStatus `json:"status"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Answer          string         `json:"answer,omitempty"`
+	AnsweredBy      int64          `json:"answered_by,omitempty"`
+	NotifyChatID    int64          `json:"notify_chat_id,omitempty"`
+	NotifyMessageID int            `json:"notify_message_id,omitempty"`
+	AssigneeID      int64          `json:"assignee_id,omitempty"`
+	ClaimedAt       *time.Time     `json:"claimed_at,omitempty"`
+	FirstActionAt   *time.Time     `json:"first_action_at,omitempty"`
+	FirstActionBy   int64          `json:"first_action_by,omitempty"`
+	CheckedAt       *time.Time     `json:"checked_at,omitempty"`
+	AnsweredAt      *time.Time     `json:"answered_at,omitempty"`
+	PublishedAt     *time.Time     `json:"published_at,omitempty"`
+	// ClusterID - основной вопрос, дубликатом которого является этот. 0 - вопрос сам основной
+	ClusterID int `json:"cluster_id,omitempty"`
+	// Duplicates - сколько вопросов объединено с этим как с основным
+	Duplicates int `json:"duplicates,omitempty"`
+	// Votes - сколько раз проголосовали за вопрос и его дубликаты
+	Votes int `json:"votes,omitempty"`
+}
+
+// QuestionSort - порядок вопросов в списках панели
+type QuestionSort string
+
+const (
+	SortLatest  QuestionSort = "latest"
+	SortPopular QuestionSort = "popular"
+)
+
+// SimilarQuestion - вопрос и его сходство с другим текстом от 0 до 1
+type SimilarQuestion struct {
+	Question
+	Similarity float64 `json:"similarity"`
+}
+
+// IsOpen - на вопрос еще можно ответить
+func (q Question) IsOpen() bool {
+	return q.Status == QuestionNew || q.Status == QuestionChecked
+}
+
+// RootID - основной вопрос кластера
+func (q Question) RootID() int {
+	if q.ClusterID != 0 {
+		return q.ClusterID
+	}
+	return q.ID
+}
+
+func (q Question) String() string {
+	return fmt.Sprintf("(id: %d | user_id: %d | status: %s | created_at: %v)",
+		q.ID, q.UserID, q.Status, q.CreatedAt)
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// RelayTopic - тема форума в группе поддержки, через которую идет переписка с пользователем
+type RelayTopic struct {
+	UserID    int64      `json:"user_id"`
+	ChatID    int64      `json:"chat_id"`
+	ThreadID  int        `json:"thread_id"`
+	IsOpen    bool       `json:"is_open"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+func (r RelayTopic) String() string {
+	return fmt.Sprintf("(user_id: %d | chat_id: %d | thread_id: %d | is_open: %t)",
+		r.UserID, r.ChatID, r.ThreadID, r.IsOpen)
+}
@@ -0,0 +1,15 @@
+package entity
+
+// SearchResult - найденный вопрос. В Snippet совпадения обрамлены SnippetStart и SnippetStop
+type SearchResult struct {
+	Question
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
+
+// SnippetStart, SnippetStop - управляющие символы не встречаются в тексте вопросов,
+// поэтому их можно безопасно заменить на разметку после экранирования текста
+const (
+	SnippetStart = "\x02"
+	SnippetStop  = "\x03"
+)
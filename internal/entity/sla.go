@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+// ResponseStats - скорость реакции администратора за период. AdminID = 0 - итог по всем
+type ResponseStats struct {
+	AdminID        int64         `json:"admin_id"`
+	AdminUsername  string        `json:"admin_username"`
+	FirstActions   int           `json:"first_actions"`
+	AvgFirstAction time.Duration `json:"avg_first_action"`
+	Answers        int           `json:"answers"`
+	AvgAnswer      time.Duration `json:"avg_answer"`
+}
+
+type StatsPeriod string
+
+const (
+	StatsDay   StatsPeriod = "day"
+	StatsWeek  StatsPeriod = "week"
+	StatsMonth StatsPeriod = "month"
+)
+
+func (p StatsPeriod) Title() string {
+	switch p {
+	case StatsDay:
+		return "сутки"
+	case StatsWeek:
+		return "неделю"
+	case StatsMonth:
+		return "месяц"
+	}
+	return string(p)
+}
+
+func (p StatsPeriod) Duration() time.Duration {
+	switch p {
+	case StatsWeek:
+		return 7 * 24 * time.Hour
+	case StatsMonth:
+		return 30 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func (p StatsPeriod) IsValid() bool {
+	return p == StatsDay || p == StatsWeek || p == StatsMonth
+}
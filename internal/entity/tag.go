@@ -0,0 +1,6 @@
+package entity
+
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
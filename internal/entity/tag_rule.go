@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+type TagRuleKind string
+
+const (
+	// TagRuleKeyword - слова и фразы, сравниваются по основам слов
+	TagRuleKeyword TagRuleKind = "keyword"
+	// TagRuleRegex - регулярные выражения по исходному тексту без учета регистра
+	TagRuleRegex TagRuleKind = "regex"
+)
+
+func (k TagRuleKind) IsValid() bool {
+	return k == TagRuleKeyword || k == TagRuleRegex
+}
+
+// TagRule - правило автоматической разметки. Правила проверяются по возрастанию Position,
+// Weight - уверенность правила при совпадении всех признаков
+type TagRule struct {
+	ID        int         `json:"id"`
+	TagID     int         `json:"tag_id"`
+	TagName   string      `json:"tag_name"`
+	Kind      TagRuleKind `json:"kind"`
+	Patterns  []string    `json:"patterns"`
+	Weight    float64     `json:"weight"`
+	Position  int         `json:"position"`
+	CreatedBy int64       `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// QuestionTag - тег вопроса. RuleID = 0 означает, что тег поставлен вручную
+type QuestionTag struct {
+	Tag
+	RuleID     int     `json:"rule_id"`
+	Confidence float64 `json:"confidence"`
+}
+
+func (q QuestionTag) IsAuto() bool {
+	return q.RuleID != 0
+}
+
+type TagCorrectionAction string
+
+const (
+	// TagCorrectionAdded - администратор добавил тег, который правила не нашли
+	TagCorrectionAdded TagCorrectionAction = "added"
+	// TagCorrectionRemoved - администратор снял тег, поставленный правилом
+	TagCorrectionRemoved TagCorrectionAction = "removed"
+)
+
+type TagCorrection struct {
+	QuestionID int                 `json:"question_id"`
+	TagID      int                 `json:"tag_id"`
+	RuleID     int                 `json:"rule_id"`
+	Action     TagCorrectionAction `json:"action"`
+	AdminID    int64               `json:"admin_id"`
+}
+
+// TagRuleStats - сколько раз правило сработало, сколько его срабатываний сняли вручную
+// и сколько раз тег правила пришлось добавить вручную
+type TagRuleStats struct {
+	RuleID   int `json:"rule_id"`
+	Assigned int `json:"assigned"`
+	Removed  int `json:"removed"`
+	Missed   int `json:"missed"`
+}
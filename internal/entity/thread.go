@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+type ThreadKind string
+
+const (
+	// ThreadFollowUp - уточнение автора, присланное ответом на сообщение бота
+	ThreadFollowUp ThreadKind = "followup"
+	ThreadAnswer   ThreadKind = "answer"
+)
+
+// ThreadMessage - сообщение переписки по вопросу после его создания
+type ThreadMessage struct {
+	ID         int        `json:"id"`
+	QuestionID int        `json:"question_id"`
+	Kind       ThreadKind `json:"kind"`
+	AuthorID   int64      `json:"author_id"`
+	Text       string     `json:"text"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
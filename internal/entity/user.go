@@ -11,14 +11,47 @@ const (
 	UserType       UserRole = "user"
 	AdminType      UserRole = "admin"
 	SuperAdminType UserRole = "superAdmin"
+	AnalystType    UserRole = "analyst"
+	ModeratorType  UserRole = "moderator"
+	EditorType     UserRole = "editor"
 )
 
+// AssignableRoles - роли, которые можно выдать из панели управления
+var AssignableRoles = []UserRole{AdminType, AnalystType, ModeratorType, EditorType}
+
+func (r UserRole) Title() string {
+	switch r {
+	case UserType:
+		return "Пользователь"
+	case AdminType:
+		return "Администратор"
+	case SuperAdminType:
+		return "Супер администратор"
+	case AnalystType:
+		return "Аналитик"
+	case ModeratorType:
+		return "Модератор"
+	case EditorType:
+		return "Редактор"
+	}
+	return string(r)
+}
+
+func (r UserRole) IsValid() bool {
+	switch r {
+	case UserType, AdminType, SuperAdminType, AnalystType, ModeratorType, EditorType:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID          int64     `json:"id,omitempty"`
 	TGUsername  string    `json:"tg_username"`
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 	ChannelFrom string    `json:"channel_from,omitempty"`
 	UserRole    UserRole  `json:"user_role,omitempty"`
+	IsBanned    bool      `json:"is_banned,omitempty"`
 }
 
 func (u User) String() string {
@@ -0,0 +1,194 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/excel"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// auditFileName - имя выгрузки журнала в Telegram, сам файл создается во временном каталоге
+const auditFileName = "audit_log.xlsx"
+
+type CallbackAudit interface {
+	AuditLog() tgbot.ViewFunc
+	AuditPage() tgbot.ViewFunc
+	AuditExport() tgbot.ViewFunc
+}
+
+type callbackAudit struct {
+	auditService service.AuditService
+	log          *logger.Logger
+	tgMsg        customMsg.Message
+	excel        *excel.Excel
+}
+
+func NewCallbackAudit(
+	auditService service.AuditService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+	excel *excel.Excel,
+) (CallbackAudit, error) {
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+	if excel == nil {
+		return nil, errors.New("excel is nil")
+	}
+
+	return &callbackAudit{
+		auditService: auditService,
+		log:          log,
+		tgMsg:        tgMsg,
+		excel:        excel,
+	}, nil
+}
+
+// AuditLog - audit_log
+func (c *callbackAudit) AuditLog() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendPage(ctx, update, 0)
+	}
+}
+
+// AuditPage - audit_page:{page}
+func (c *callbackAudit) AuditPage() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		page, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		return c.sendPage(ctx, update, page)
+	}
+}
+
+// AuditExport - audit_export
+func (c *callbackAudit) AuditExport() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		logs, err := c.auditService.GetAll(ctx)
+		if err != nil {
+			c.log.Error("AuditExport: auditService.GetAll: %v", err)
+			return customErr.ErrServerError
+		}
+
+		results := make([]excel.Audit, 0, len(logs))
+		for _, l := range logs {
+			diff, err := json.Marshal(l.Diff)
+			if err != nil {
+				c.log.Error("AuditExport: json.Marshal: failed to marshal diff %d: %v", l.ID, err)
+			}
+
+			results = append(results, excel.Audit{
+				ID:            l.ID,
+				CreatedAt:     l.CreatedAt,
+				ActorID:       l.ActorID,
+				ActorUsername: l.ActorUsername,
+				Action:        string(l.Action),
+				Target:        l.Target,
+				Diff:          string(diff),
+			})
+		}
+
+		fileName, err := c.excel.GenerateAuditExcelFile(results, update.CallbackQuery.From.UserName)
+		if err != nil {
+			c.log.Error("Excel.GenerateAuditExcelFile: failed to generate excel file: %v", err)
+			return err
+		}
+		defer c.excel.RemoveExcelFile(fileName)
+
+		fileIDBytes, err := c.excel.GetExcelFile(fileName)
+		if err != nil {
+			c.log.Error("Excel.GetExcelFile: failed to get excel file: %v", err)
+			return err
+		}
+
+		if fileIDBytes == nil {
+			c.log.Error("fileIDBytes is nil")
+			return errors.New("ошибка в обработке файла")
+		}
+
+		if _, err := c.tgMsg.SendDocument(ctx, update.FromChat().ID,
+			auditFileName,
+			fileIDBytes,
+			"Журнал действий администраторов",
+		); err != nil {
+			return err
+		}
+
+		c.auditService.Log(ctx, update.CallbackQuery.From.ID, entity.AuditAuditExport, auditFileName, entity.AuditDiff{
+			"records": {New: len(logs)},
+		})
+
+		return nil
+	}
+}
+
+func (c *callbackAudit) sendPage(ctx context.Context, update *tgbotapi.Update, page int) error {
+	logs, pages, err := c.auditService.GetPage(ctx, page)
+	if err != nil {
+		c.log.Error("auditService.GetPage: %v", err)
+		return customErr.ErrServerError
+	}
+	// GetPage вернул страницу, ограниченную [0, pages-1], подпись и кнопки должны совпадать с ней
+	page = min(max(page, 0), pages-1)
+
+	auditMarkup := markup.AuditPage(page, pages)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&auditMarkup,
+		auditPageText(logs, page, pages)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func auditPageText(logs []entity.AuditLog, page, pages int) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<b>Журнал действий</b> (страница %d из %d)\n\n", page+1, pages))
+	if len(logs) == 0 {
+		sb.WriteString("Записей пока нет")
+		return sb.String()
+	}
+
+	for _, l := range logs {
+		actor := strconv.FormatInt(l.ActorID, 10)
+		if l.ActorUsername != "" {
+			actor = "@" + l.ActorUsername
+		}
+
+		sb.WriteString(fmt.Sprintf("%s | %s\n<b>%s</b> → %s\n",
+			l.CreatedAt.Format("02.01.2006 15:04"),
+			html.EscapeString(actor),
+			l.Action,
+			html.EscapeString(l.Target)))
+
+		for field, change := range l.Diff {
+			sb.WriteString(html.EscapeString(fmt.Sprintf("  %s: %v → %v", field, change.Old, change.New)))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,127 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"time"
+)
+
+type CallbackDigest interface {
+	DigestSetting() tgbot.ViewFunc
+	DigestToggle() tgbot.ViewFunc
+	DigestPreview() tgbot.ViewFunc
+}
+
+type callbackDigest struct {
+	digestService service.DigestService
+	log           *logger.Logger
+	tgMsg         customMsg.Message
+}
+
+func NewCallbackDigest(
+	digestService service.DigestService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (CallbackDigest, error) {
+	if digestService == nil {
+		return nil, errors.New("digestService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackDigest{
+		digestService: digestService,
+		log:           log,
+		tgMsg:         tgMsg,
+	}, nil
+}
+
+// DigestSetting - digest_setting
+func (c *callbackDigest) DigestSetting() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendSetting(ctx, update)
+	}
+}
+
+// DigestToggle - digest_toggle:{kind}
+func (c *callbackDigest) DigestToggle() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		arg, err := action.FromContext(ctx).String(0)
+		if err != nil {
+			return err
+		}
+
+		kind := entity.DigestKind(arg)
+		if !kind.IsValid() {
+			return customErr.ErrInvalidRequest
+		}
+
+		if _, err := c.digestService.ToggleSubscription(ctx, update.CallbackQuery.From.ID, kind); err != nil {
+			c.log.Error("digestService.ToggleSubscription: %v", err)
+			return customErr.ErrServerError
+		}
+
+		return c.sendSetting(ctx, update)
+	}
+}
+
+// DigestPreview - digest_preview
+func (c *callbackDigest) DigestPreview() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		now := time.Now().Local()
+
+		digest, err := c.digestService.Build(ctx, entity.DigestDaily, now.Add(-entity.DigestDaily.Period()), now)
+		if err != nil {
+			c.log.Error("digestService.Build: %v", err)
+			return customErr.ErrServerError
+		}
+
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, nil, c.digestService.Format(digest)); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (c *callbackDigest) sendSetting(ctx context.Context, update *tgbotapi.Update) error {
+	subscriptions, err := c.digestService.GetSubscriptions(ctx, update.CallbackQuery.From.ID)
+	if err != nil {
+		c.log.Error("digestService.GetSubscriptions: %v", err)
+		return customErr.ErrServerError
+	}
+
+	var daily, weekly bool
+	for _, s := range subscriptions {
+		switch s {
+		case entity.DigestDaily:
+			daily = true
+		case entity.DigestWeekly:
+			weekly = true
+		}
+	}
+
+	digestMarkup := markup.DigestSetting(daily, weekly)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&digestMarkup,
+		"Дайджест: новые вопросы, нерешенные вопросы, новые пользователи и частые темы.\n"+
+			"Нажмите на кнопку, чтобы подписаться или отписаться"); err != nil {
+		return err
+	}
+
+	return nil
+}
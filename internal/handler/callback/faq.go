@@ -0,0 +1,243 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// faqTitleLen - длина подписи записи FAQ на кнопке удаления
+const faqTitleLen = 40
+
+type CallbackFaq interface {
+	FaqSetting() tgbot.ViewFunc
+	FaqCreate() tgbot.ViewFunc
+	FaqDialog() dialog.Dialog
+	FaqDelete() tgbot.ViewFunc
+
+	FaqHelped() tgbot.ViewFunc
+	FaqSend() tgbot.ViewFunc
+}
+
+type callbackFaq struct {
+	faqService service.FaqService
+	log        *logger.Logger
+	dialogs    *dialog.Engine
+	tgMsg      customMsg.Message
+}
+
+func NewCallbackFaq(
+	faqService service.FaqService,
+	log *logger.Logger,
+	dialogs *dialog.Engine,
+	tgMsg customMsg.Message,
+) (CallbackFaq, error) {
+	if faqService == nil {
+		return nil, errors.New("faqService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackFaq{
+		faqService: faqService,
+		log:        log,
+		dialogs:    dialogs,
+		tgMsg:      tgMsg,
+	}, nil
+}
+
+// FaqSetting - faq_setting
+func (c *callbackFaq) FaqSetting() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendFaqSetting(ctx, update)
+	}
+}
+
+// FaqCreate - faq_create
+func (c *callbackFaq) FaqCreate() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.dialogs.Start(ctx, update, store.FaqCreate, nil)
+	}
+}
+
+type faqDialog struct {
+	Patterns string `json:"patterns"`
+	Answer   string `json:"answer"`
+}
+
+// FaqDialog - новая запись FAQ: сначала признаки вопроса, затем ответ
+func (c *callbackFaq) FaqDialog() dialog.Dialog {
+	return &dialog.Flow[faqDialog]{
+		Name: store.FaqCreate,
+		Steps: []dialog.Step[faqDialog]{
+			{
+				Prompt: func(data *faqDialog) string {
+					return "Напишите слова или фразы через «;», по которым узнается вопрос.\n\n" +
+						"Пример: ключевая ставка; ставка ЦБ"
+				},
+				Apply: func(ctx context.Context, data *faqDialog, text string) error {
+					if len(service.SplitFaqPatterns(text)) == 0 {
+						return dialog.InputError("Укажите хотя бы одно слово или фразу")
+					}
+					data.Patterns = text
+					return nil
+				},
+			},
+			{
+				Prompt: func(data *faqDialog) string {
+					return fmt.Sprintf("Признаки: <i>%s</i>\n\nНапишите текст ответа или ссылку на пост в канале.",
+						html.EscapeString(strings.Join(service.SplitFaqPatterns(data.Patterns), "; ")))
+				},
+				Apply: func(ctx context.Context, data *faqDialog, text string) error {
+					data.Answer = strings.TrimSpace(text)
+					if data.Answer == "" {
+						return dialog.InputError("Ответ не может быть пустым")
+					}
+					return nil
+				},
+			},
+		},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *faqDialog) (*dialog.Reply, error) {
+			if _, err := c.faqService.CreateFaq(ctx, update.Message.From.ID, data.Patterns, data.Answer); err != nil {
+				return nil, err
+			}
+			return &dialog.Reply{
+				Text:    success + "Ответ будет предлагаться на похожие вопросы.",
+				Markup:  &markup.FaqBack,
+				Cleanup: true,
+			}, nil
+		},
+	}
+}
+
+// FaqDelete - faq_delete:{id}
+func (c *callbackFaq) FaqDelete() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.faqService.DeleteFaq(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, "Ответ удален")
+
+		return c.sendFaqSetting(ctx, update)
+	}
+}
+
+// FaqHelped - faq_helped:{suggestion_id}
+func (c *callbackFaq) FaqHelped() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		if err := c.resolve(ctx, update, entity.FaqHelped); err != nil {
+			return err
+		}
+
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.CallbackQuery.Message.Chat.ID, nil,
+			"Рады, что ответ нашелся! Если появятся другие вопросы, просто напишите их сюда"); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// FaqSend - faq_send:{suggestion_id}
+func (c *callbackFaq) FaqSend() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.resolve(ctx, update, entity.FaqSent)
+	}
+}
+
+// resolve - после решения пользователя кнопки под подсказкой убираются
+func (c *callbackFaq) resolve(ctx context.Context, update *tgbotapi.Update, outcome entity.FaqOutcome) error {
+	id, err := callbackID(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := c.faqService.Resolve(ctx, update.CallbackQuery.From.ID, id, outcome); err != nil {
+		return err
+	}
+
+	emptyMarkup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if err := c.tgMsg.SendEditMarkup(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		emptyMarkup); err != nil {
+		c.log.Error("failed to remove faq suggestion buttons: %v", err)
+	}
+
+	return nil
+}
+
+func (c *callbackFaq) sendFaqSetting(ctx context.Context, update *tgbotapi.Update) error {
+	faqs, stats, err := c.faqService.GetAll(ctx)
+	if err != nil {
+		c.log.Error("faqService.GetAll: %v", err)
+		return customErr.ErrServerError
+	}
+
+	statsByFaq := make(map[int]entity.FaqStats, len(stats))
+	for _, st := range stats {
+		statsByFaq[st.FaqID] = st
+	}
+
+	var (
+		sb      strings.Builder
+		buttons = make([][2]string, 0, len(faqs))
+	)
+	sb.WriteString("<b>FAQ</b>\nОтвет предлагается автору до отправки вопроса, если в вопросе нашлись признаки записи\n")
+	if len(faqs) == 0 {
+		sb.WriteString("\nЗаписей пока нет")
+	}
+
+	for i, faq := range faqs {
+		st := statsByFaq[faq.ID]
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n   показан %d, помог %d, вопрос все равно отправлен %d\n",
+			i+1,
+			html.EscapeString(strings.Join(faq.Patterns, "; ")),
+			st.Shown, st.Helped, st.Sent))
+
+		buttons = append(buttons, [2]string{strconv.Itoa(faq.ID), fmt.Sprintf("%d. %s", i+1, faqTitle(faq))})
+	}
+
+	faqMarkup := markup.FaqSetting(buttons)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&faqMarkup,
+		sb.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func faqTitle(faq entity.Faq) string {
+	title := strings.Join(faq.Patterns, "; ")
+	if utf8.RuneCountInString(title) > faqTitleLen {
+		title = string([]rune(title)[:faqTitleLen]) + "…"
+	}
+	return title
+}
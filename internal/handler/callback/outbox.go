@@ -0,0 +1,119 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+)
+
+type CallbackOutbox interface {
+	OutboxList() tgbot.ViewFunc
+	OutboxRetry() tgbot.ViewFunc
+	OutboxRequeue() tgbot.ViewFunc
+}
+
+type callbackOutbox struct {
+	outboxService service.OutboxService
+	log           *logger.Logger
+	tgMsg         customMsg.Message
+}
+
+func NewCallbackOutbox(
+	outboxService service.OutboxService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (CallbackOutbox, error) {
+	if outboxService == nil {
+		return nil, errors.New("outboxService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackOutbox{
+		outboxService: outboxService,
+		log:           log,
+		tgMsg:         tgMsg,
+	}, nil
+}
+
+// OutboxList - outbox_list
+func (c *callbackOutbox) OutboxList() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendOutboxList(ctx, update)
+	}
+}
+
+// OutboxRetry - outbox_retry:{id}
+func (c *callbackOutbox) OutboxRetry() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := action.FromContext(ctx).Int64(0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.outboxService.Retry(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, "Сообщение поставлено в очередь")
+
+		return c.sendOutboxList(ctx, update)
+	}
+}
+
+// OutboxRequeue - outbox_requeue, повтор всех недоставленных сообщений
+func (c *callbackOutbox) OutboxRequeue() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		count, err := c.outboxService.RetryAll(ctx, update.CallbackQuery.From.ID)
+		if err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, fmt.Sprintf("В очередь возвращено сообщений: %d", count))
+
+		return c.sendOutboxList(ctx, update)
+	}
+}
+
+func (c *callbackOutbox) sendOutboxList(ctx context.Context, update *tgbotapi.Update) error {
+	stats, err := c.outboxService.GetStats(ctx)
+	if err != nil {
+		c.log.Error("outboxService.GetStats: %v", err)
+		return customErr.ErrServerError
+	}
+
+	messages, err := c.outboxService.GetDead(ctx)
+	if err != nil {
+		c.log.Error("outboxService.GetDead: %v", err)
+		return customErr.ErrServerError
+	}
+
+	buttons := make([][2]string, 0, len(messages))
+	for _, message := range messages {
+		buttons = append(buttons, [2]string{
+			strconv.FormatInt(message.ID, 10),
+			fmt.Sprintf("#%d → %d", message.ID, message.ChatID),
+		})
+	}
+
+	outboxMarkup := markup.OutboxDead(buttons)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&outboxMarkup,
+		c.outboxService.FormatDead(stats, messages)); err != nil {
+		return err
+	}
+
+	return nil
+}
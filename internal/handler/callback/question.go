@@ -0,0 +1,502 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+)
+
+type CallbackQuestion interface {
+	QuestionCheck() tgbot.ViewFunc
+	QuestionAnswer() tgbot.ViewFunc
+	QuestionReject() tgbot.ViewFunc
+	QuestionBan() tgbot.ViewFunc
+	QuestionPublish() tgbot.ViewFunc
+	QuestionClaim() tgbot.ViewFunc
+	QuestionRelease() tgbot.ViewFunc
+	QuestionDelegate() tgbot.ViewFunc
+	QuestionHandTo() tgbot.ViewFunc
+	QuestionCard() tgbot.ViewFunc
+	MyQuestions() tgbot.ViewFunc
+
+	QuestionSimilar() tgbot.ViewFunc
+	QuestionMerge() tgbot.ViewFunc
+	QuestionDetach() tgbot.ViewFunc
+
+	ReplyAnswer() tgbot.ViewFunc
+	AnswerDialog() dialog.Dialog
+}
+
+type callbackQuestion struct {
+	questionService service.QuestionService
+	userService     service.UserService
+	log             *logger.Logger
+	dialogs         *dialog.Engine
+	tgMsg           customMsg.Message
+}
+
+func NewCallbackQuestion(
+	questionService service.QuestionService,
+	userService service.UserService,
+	log *logger.Logger,
+	dialogs *dialog.Engine,
+	tgMsg customMsg.Message,
+) (CallbackQuestion, error) {
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if userService == nil {
+		return nil, errors.New("userService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackQuestion{
+		questionService: questionService,
+		userService:     userService,
+		log:             log,
+		dialogs:         dialogs,
+		tgMsg:           tgMsg,
+	}, nil
+}
+
+// QuestionCheck - q_check:{id}
+func (c *callbackQuestion) QuestionCheck() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Check(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return c.closedAlert(ctx, update, id, err)
+		}
+
+		tgbot.Toast(ctx, "Вопрос отмечен как проверенный")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionAnswer - q_answer:{id}
+func (c *callbackQuestion) QuestionAnswer() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		// ответ закрепляет вопрос, чтобы двое аналитиков не ответили одному человеку
+		if err := c.questionService.Claim(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		return c.dialogs.Start(ctx, update, store.QuestionAnswer, answerDialog{QuestionID: id})
+	}
+}
+
+type answerDialog struct {
+	QuestionID int    `json:"question_id"`
+	Answer     string `json:"answer"`
+}
+
+// AnswerDialog - ответ на вопрос после q_answer:{id}. Карточка вопроса при этом не удаляется
+func (c *callbackQuestion) AnswerDialog() dialog.Dialog {
+	return &dialog.Flow[answerDialog]{
+		Name: store.QuestionAnswer,
+		Steps: []dialog.Step[answerDialog]{{
+			Prompt: func(data *answerDialog) string {
+				return fmt.Sprintf("Напишите ответ на вопрос #%d, он будет отправлен пользователю.", data.QuestionID)
+			},
+			Apply: func(ctx context.Context, data *answerDialog, text string) error {
+				data.Answer = strings.TrimSpace(text)
+				if data.Answer == "" {
+					return dialog.InputError("Ответ должен быть текстом")
+				}
+				return nil
+			},
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *answerDialog) (*dialog.Reply, error) {
+			if err := c.questionService.Answer(ctx, update.Message.From.ID, data.QuestionID, data.Answer); err != nil {
+				return nil, err
+			}
+			return &dialog.Reply{
+				Text: fmt.Sprintf("Ответ на вопрос #%d отправлен пользователю", data.QuestionID),
+			}, nil
+		},
+	}
+}
+
+// QuestionReject - q_reject:{id}
+func (c *callbackQuestion) QuestionReject() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Reject(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return c.closedAlert(ctx, update, id, err)
+		}
+
+		tgbot.Toast(ctx, "Вопрос отклонен")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionBan - q_ban:{id}
+func (c *callbackQuestion) QuestionBan() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.BanAuthor(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Автор вопроса заблокирован")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionPublish - q_publish:{id}
+func (c *callbackQuestion) QuestionPublish() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Publish(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Вопрос отмечен как опубликованный")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionClaim - q_claim:{id}
+func (c *callbackQuestion) QuestionClaim() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Claim(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Вопрос закреплен за вами")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionRelease - q_release:{id}
+func (c *callbackQuestion) QuestionRelease() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Release(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Вопрос снят с вас")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionDelegate - q_delegate:{id}
+func (c *callbackQuestion) QuestionDelegate() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		admins, err := c.userService.GetUsersWithPermission(ctx, entity.PermQuestionAnswer)
+		if err != nil {
+			c.log.Error("userService.GetUsersWithPermission: %v", err)
+			return customErr.ErrServerError
+		}
+
+		buttons := make([][2]string, 0, len(admins))
+		for _, admin := range admins {
+			title := admin.TGUsername
+			if title == "" {
+				title = strconv.FormatInt(admin.ID, 10)
+			}
+			buttons = append(buttons, [2]string{strconv.FormatInt(admin.ID, 10), title + " — " + admin.UserRole.Title()})
+		}
+
+		delegateMarkup := markup.QuestionDelegate(id, buttons)
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&delegateMarkup,
+			fmt.Sprintf("Кому передать вопрос #%d?", id)); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// QuestionHandTo - q_handto:{id}:{user_id}
+func (c *callbackQuestion) QuestionHandTo() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		data := action.FromContext(ctx)
+
+		id, err := data.Int(0)
+		if err != nil {
+			return err
+		}
+		assigneeID, err := data.Int64(1)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Assign(ctx, update.CallbackQuery.From.ID, id, assigneeID); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Вопрос передан")
+		return c.sendCard(ctx, update, id)
+	}
+}
+
+// QuestionCard - q_card:{id}
+func (c *callbackQuestion) QuestionCard() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		return c.sendCard(ctx, update, id)
+	}
+}
+
+// MyQuestions - my_questions
+func (c *callbackQuestion) MyQuestions() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		questions, err := c.questionService.GetAssigned(ctx, update.CallbackQuery.From.ID)
+		if err != nil {
+			c.log.Error("questionService.GetAssigned: %v", err)
+			return customErr.ErrServerError
+		}
+
+		text := "Вопросы, закрепленные за вами"
+		if len(questions) == 0 {
+			text = "За вами нет открытых вопросов"
+		}
+
+		listMarkup := markup.QuestionList(questionButtons(questions))
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&listMarkup,
+			text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// QuestionSimilar - q_similar:{id}
+func (c *callbackQuestion) QuestionSimilar() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		similar, err := c.questionService.GetSimilar(ctx, id)
+		if err != nil {
+			c.log.Error("questionService.GetSimilar: %v", err)
+			return customErr.ErrServerError
+		}
+
+		text := fmt.Sprintf("Похожие вопросы. Нажмите на вопрос, чтобы объединить его с #%d", id)
+		if len(similar) == 0 {
+			text = "Похожих вопросов не найдено"
+		}
+
+		buttons := make([][2]string, 0, len(similar))
+		for _, s := range similar {
+			buttons = append(buttons, [2]string{strconv.Itoa(s.ID),
+				fmt.Sprintf("%.0f%% #%d %s", s.Similarity*100, s.ID, shorten(s.Question.Question, 35))})
+		}
+
+		similarMarkup := markup.QuestionSimilar(id, buttons)
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&similarMarkup,
+			text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// QuestionMerge - q_merge:{id}:{duplicate_id}
+func (c *callbackQuestion) QuestionMerge() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		data := action.FromContext(ctx)
+
+		id, err := data.Int(0)
+		if err != nil {
+			return err
+		}
+		dupID, err := data.Int(1)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Merge(ctx, update.CallbackQuery.From.ID, id, dupID); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, fmt.Sprintf("Вопрос #%d объединен с #%d", dupID, id))
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// QuestionDetach - q_detach:{id}
+func (c *callbackQuestion) QuestionDetach() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.questionService.Detach(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+
+		tgbot.Toast(ctx, "Вопрос отделен от основного")
+		return c.refreshCard(ctx, update, id)
+	}
+}
+
+// ReplyAnswer - ответ администратора реплаем на уведомление о вопросе
+func (c *callbackQuestion) ReplyAnswer() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		question, err := c.questionService.GetQuestionByNotifyMessage(ctx,
+			update.Message.Chat.ID,
+			update.Message.ReplyToMessage.MessageID)
+		if err != nil {
+			// реплай не на уведомление о вопросе
+			if errors.Is(err, customErr.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		if update.Message.Text == "" {
+			return customErr.ErrInvalidRequest
+		}
+
+		return c.questionService.Answer(ctx, update.Message.From.ID, question.ID, update.Message.Text)
+	}
+}
+
+// sendCard - показывает карточку вопроса в сообщении, на кнопку которого нажали
+func (c *callbackQuestion) sendCard(ctx context.Context, update *tgbotapi.Update, id int) error {
+	text, cardMarkup, err := c.questionService.Card(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&cardMarkup,
+		text); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// refreshCard - уведомление в чате администраторов обновляет сервис, здесь обновляются остальные копии карточки
+// closedAlert - если вопрос успели закрыть, администратор видит его настоящий статус, остальные ошибки возвращаются как есть
+func (c *callbackQuestion) closedAlert(ctx context.Context, update *tgbotapi.Update, id int, err error) error {
+	if !errors.Is(err, customErr.ErrQuestionClosed) {
+		return err
+	}
+
+	question, err := c.questionService.GetQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tgbot.Alert(ctx, fmt.Sprintf("Вопрос #%d уже закрыт, статус: %s", id, question.Status.Title()))
+	return c.refreshCard(ctx, update, id)
+}
+
+func (c *callbackQuestion) refreshCard(ctx context.Context, update *tgbotapi.Update, id int) error {
+	question, err := c.questionService.GetQuestionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if question.NotifyChatID == update.CallbackQuery.Message.Chat.ID &&
+		question.NotifyMessageID == update.CallbackQuery.Message.MessageID {
+		return nil
+	}
+
+	return c.sendCard(ctx, update, id)
+}
+
+func questionButtons(questions []entity.Question) [][2]string {
+	buttons := make([][2]string, 0, len(questions))
+	for _, q := range questions {
+		title := fmt.Sprintf("#%d %s", q.ID, shorten(q.Question, 40))
+		if q.Duplicates > 0 {
+			title = fmt.Sprintf("#%d ×%d %s", q.ID, q.Duplicates+1, shorten(q.Question, 36))
+		}
+		if q.Votes > 0 {
+			title = fmt.Sprintf("👍%d %s", q.Votes, title)
+		}
+		buttons = append(buttons, [2]string{strconv.Itoa(q.ID), title})
+	}
+	return buttons
+}
+
+func shorten(s string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// callbackID - числовой параметр i нажатой кнопки
+func callbackID(ctx context.Context, i int) (int, error) {
+	return action.FromContext(ctx).Int(i)
+}
@@ -0,0 +1,111 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+)
+
+type CallbackRelay interface {
+	QuestionRelay() tgbot.ViewFunc
+	RelayClose() tgbot.ViewFunc
+	RelayReopen() tgbot.ViewFunc
+}
+
+type callbackRelay struct {
+	relayService    service.RelayService
+	questionService service.QuestionService
+	log             *logger.Logger
+	tgMsg           customMsg.Message
+}
+
+func NewCallbackRelay(
+	relayService service.RelayService,
+	questionService service.QuestionService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (CallbackRelay, error) {
+	if relayService == nil {
+		return nil, errors.New("relayService is nil")
+	}
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackRelay{
+		relayService:    relayService,
+		questionService: questionService,
+		log:             log,
+		tgMsg:           tgMsg,
+	}, nil
+}
+
+// QuestionRelay - q_relay:{id}
+func (c *callbackRelay) QuestionRelay() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		question, err := c.questionService.GetQuestionByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		topic, err := c.relayService.Open(ctx, update.CallbackQuery.From.ID, question.UserID)
+		if err != nil {
+			return err
+		}
+
+		text := fmt.Sprintf("Диалог с автором вопроса #%d открыт: %s", id, topicLink(topic.ChatID, topic.ThreadID))
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.CallbackQuery.Message.Chat.ID, nil, text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// RelayClose - /close в теме переписки
+func (c *callbackRelay) RelayClose() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		topic, err := c.relayService.TopicOf(ctx, update.Message)
+		if err != nil {
+			return err
+		}
+
+		return c.relayService.Close(ctx, update.Message.From.ID, topic.UserID)
+	}
+}
+
+// RelayReopen - /reopen в теме переписки
+func (c *callbackRelay) RelayReopen() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		topic, err := c.relayService.TopicOf(ctx, update.Message)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.relayService.Open(ctx, update.Message.From.ID, topic.UserID)
+		return err
+	}
+}
+
+// topicLink - ссылка на тему супергруппы, id супергруппы в ссылке указывается без префикса -100
+func topicLink(chatID int64, threadID int) string {
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(strconv.FormatInt(chatID, 10), "-100"), threadID)
+}
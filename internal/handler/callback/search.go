@@ -0,0 +1,188 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"strings"
+)
+
+type CallbackSearch interface {
+	// SearchCommand - /search <запрос>
+	SearchCommand() tgbot.ViewFunc
+	SearchStart() tgbot.ViewFunc
+	// SearchDialog - запрос, отправленный после нажатия кнопки поиска в панели
+	SearchDialog() dialog.Dialog
+	SearchPage() tgbot.ViewFunc
+}
+
+type callbackSearch struct {
+	questionService service.QuestionService
+	log             *logger.Logger
+	dialogs         *dialog.Engine
+	tgMsg           customMsg.Message
+}
+
+func NewCallbackSearch(
+	questionService service.QuestionService,
+	log *logger.Logger,
+	dialogs *dialog.Engine,
+	tgMsg customMsg.Message,
+) (CallbackSearch, error) {
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackSearch{
+		questionService: questionService,
+		log:             log,
+		dialogs:         dialogs,
+		tgMsg:           tgMsg,
+	}, nil
+}
+
+func (c *callbackSearch) SearchCommand() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		query := strings.TrimSpace(update.Message.CommandArguments())
+		if query == "" {
+			if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, nil,
+				"Напишите запрос после команды, например: /search ипотека"); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		return c.search(ctx, update, query)
+	}
+}
+
+// SearchStart - search_start
+func (c *callbackSearch) SearchStart() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.dialogs.Start(ctx, update, store.SearchQuery, nil)
+	}
+}
+
+type searchDialog struct {
+	Query string `json:"query"`
+}
+
+// SearchDialog - запрос, введенный после search_start
+func (c *callbackSearch) SearchDialog() dialog.Dialog {
+	return &dialog.Flow[searchDialog]{
+		Name: store.SearchQuery,
+		Steps: []dialog.Step[searchDialog]{{
+			Prompt: func(data *searchDialog) string {
+				return "Напишите, что найти в вопросах и ответах, например: ипотека.\n" +
+					"Фразу можно взять в кавычки, слово исключить минусом: \"ключевая ставка\" -кредит."
+			},
+			Apply: func(ctx context.Context, data *searchDialog, text string) error {
+				data.Query = strings.TrimSpace(text)
+				if data.Query == "" {
+					return dialog.InputError("Запрос не может быть пустым")
+				}
+				return nil
+			},
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *searchDialog) (*dialog.Reply, error) {
+			return nil, c.search(ctx, update, data.Query)
+		},
+	}
+}
+
+// SearchPage - search_page:{page}:{query}
+func (c *callbackSearch) SearchPage() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		page, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+		query, err := action.FromContext(ctx).String(1)
+		if err != nil {
+			return err
+		}
+
+		text, searchMarkup, err := c.resultPage(ctx, query, page)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&searchMarkup,
+			text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (c *callbackSearch) search(ctx context.Context, update *tgbotapi.Update, query string) error {
+	text, searchMarkup, err := c.resultPage(ctx, query, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, &searchMarkup, text); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *callbackSearch) resultPage(ctx context.Context, query string, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	results, pages, err := c.questionService.Search(ctx, query, page)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, customErr.ErrServerError
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>Поиск:</b> %s\n", html.EscapeString(query)))
+	if len(results) == 0 {
+		sb.WriteString("\nНичего не найдено")
+		return sb.String(), markup.MainMenu, nil
+	}
+	sb.WriteString(fmt.Sprintf("Страница %d из %d\n", page+1, pages))
+
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		sb.WriteString(fmt.Sprintf("\n<b>#%d</b> · %s · %s\n%s\n",
+			result.ID,
+			result.CreatedAt.Format("02.01.2006"),
+			result.Status.Title(),
+			highlight(result.Snippet)))
+		ids = append(ids, strconv.Itoa(result.ID))
+	}
+
+	return sb.String(), markup.SearchPage(ids, query, page, pages), nil
+}
+
+// highlight - экранирует сниппет и выделяет совпадения жирным
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, entity.SnippetStart, "<b>")
+	return strings.ReplaceAll(snippet, entity.SnippetStop, "</b>")
+}
@@ -0,0 +1,95 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type CallbackSLA interface {
+	SLAStats() tgbot.ViewFunc
+}
+
+type callbackSLA struct {
+	slaService service.SLAService
+	tagService service.TagService
+	log        *logger.Logger
+	tgMsg      customMsg.Message
+}
+
+func NewCallbackSLA(
+	slaService service.SLAService,
+	tagService service.TagService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (CallbackSLA, error) {
+	if slaService == nil {
+		return nil, errors.New("slaService is nil")
+	}
+	if tagService == nil {
+		return nil, errors.New("tagService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackSLA{
+		slaService: slaService,
+		tagService: tagService,
+		log:        log,
+		tgMsg:      tgMsg,
+	}, nil
+}
+
+// SLAStats - sla_stats:{period}:{tag_id}, tag_id = 0 - без фильтра по тегу
+func (c *callbackSLA) SLAStats() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		data := action.FromContext(ctx)
+
+		arg, err := data.String(0)
+		if err != nil {
+			return err
+		}
+		period := entity.StatsPeriod(arg)
+		if !period.IsValid() {
+			return customErr.ErrInvalidRequest
+		}
+		tagID, err := data.Int(1)
+		if err != nil {
+			return err
+		}
+
+		tags, err := c.tagService.GetAllTags(ctx)
+		if err != nil {
+			c.log.Error("tagService.GetAllTags: %v", err)
+			return customErr.ErrServerError
+		}
+
+		stats, err := c.slaService.GetStats(ctx, period, tagID)
+		if err != nil {
+			c.log.Error("slaService.GetStats: %v", err)
+			return customErr.ErrServerError
+		}
+
+		statsMarkup := markup.SLAStats(string(period), tagID, tagButtons(tags))
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&statsMarkup,
+			c.slaService.FormatStats(period, findTag(tags, tagID), stats)); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,407 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"strings"
+)
+
+type CallbackTag interface {
+	TagSetting() tgbot.ViewFunc
+	TagCreate() tgbot.ViewFunc
+	TagDelete() tgbot.ViewFunc
+
+	RuleList() tgbot.ViewFunc
+	RuleCreate() tgbot.ViewFunc
+	TagDialog() dialog.Dialog
+	RuleDialog() dialog.Dialog
+	RuleDelete() tgbot.ViewFunc
+
+	QuestionTags() tgbot.ViewFunc
+	QuestionTagToggle() tgbot.ViewFunc
+	QuestionFilterList() tgbot.ViewFunc
+}
+
+type callbackTag struct {
+	tagService      service.TagService
+	tagRuleService  service.TagRuleService
+	questionService service.QuestionService
+	log             *logger.Logger
+	dialogs         *dialog.Engine
+	tgMsg           customMsg.Message
+}
+
+func NewCallbackTag(
+	tagService service.TagService,
+	tagRuleService service.TagRuleService,
+	questionService service.QuestionService,
+	log *logger.Logger,
+	dialogs *dialog.Engine,
+	tgMsg customMsg.Message,
+) (CallbackTag, error) {
+	if tagService == nil {
+		return nil, errors.New("tagService is nil")
+	}
+	if tagRuleService == nil {
+		return nil, errors.New("tagRuleService is nil")
+	}
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackTag{
+		tagService:      tagService,
+		tagRuleService:  tagRuleService,
+		questionService: questionService,
+		log:             log,
+		dialogs:         dialogs,
+		tgMsg:           tgMsg,
+	}, nil
+}
+
+// TagSetting - tag_setting
+func (c *callbackTag) TagSetting() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendTagSetting(ctx, update)
+	}
+}
+
+// TagCreate - tag_create
+func (c *callbackTag) TagCreate() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.dialogs.Start(ctx, update, store.TagCreate, nil)
+	}
+}
+
+// tagDialog - данные диалогов создания тега и правила тегирования
+type tagDialog struct {
+	Input string `json:"input"`
+}
+
+func applyTagInput(ctx context.Context, data *tagDialog, text string) error {
+	data.Input = strings.TrimSpace(text)
+	if data.Input == "" {
+		return dialog.InputError("Сообщение должно содержать текст")
+	}
+	return nil
+}
+
+// TagDialog - название нового тега после tag_create
+func (c *callbackTag) TagDialog() dialog.Dialog {
+	return &dialog.Flow[tagDialog]{
+		Name: store.TagCreate,
+		Steps: []dialog.Step[tagDialog]{{
+			Prompt: func(data *tagDialog) string {
+				return "Напишите название нового тега, например: инфляция."
+			},
+			Apply: applyTagInput,
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *tagDialog) (*dialog.Reply, error) {
+			if _, err := c.tagService.CreateTag(ctx, update.Message.From.ID, data.Input); err != nil {
+				return nil, err
+			}
+			return &dialog.Reply{
+				Text:    success + "Тег добавлен в словарь.",
+				Markup:  &markup.TagBack,
+				Cleanup: true,
+			}, nil
+		},
+	}
+}
+
+// TagDelete - tag_delete:{id}
+func (c *callbackTag) TagDelete() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.tagService.DeleteTag(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, "Тег удален")
+
+		return c.sendTagSetting(ctx, update)
+	}
+}
+
+// RuleList - rule_list
+func (c *callbackTag) RuleList() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.sendRuleList(ctx, update)
+	}
+}
+
+// RuleCreate - rule_create
+func (c *callbackTag) RuleCreate() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		return c.dialogs.Start(ctx, update, store.TagRuleCreate, nil)
+	}
+}
+
+// RuleDialog - текст правила тегирования после rule_create
+func (c *callbackTag) RuleDialog() dialog.Dialog {
+	return &dialog.Flow[tagDialog]{
+		Name: store.TagRuleCreate,
+		Steps: []dialog.Step[tagDialog]{{
+			Prompt: func(data *tagDialog) string {
+				return "Напишите правило. Первая строка: тег, тип (keyword или regex) и вес от 0 до 1, " +
+					"тип и вес можно не указывать. Далее по одному слову, фразе или регулярному выражению на строку.\n\n" +
+					"Пример:\n#инфляция keyword 0.9\nинфляция\nрост цен\nподорожание"
+			},
+			Apply: applyTagInput,
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *tagDialog) (*dialog.Reply, error) {
+			if _, err := c.tagRuleService.CreateRule(ctx, update.Message.From.ID, data.Input); err != nil {
+				return nil, err
+			}
+			return &dialog.Reply{
+				Text:    success + "Правило добавлено, оно будет применяться к новым вопросам.",
+				Markup:  &markup.TagRuleBack,
+				Cleanup: true,
+			}, nil
+		},
+	}
+}
+
+// RuleDelete - rule_delete:{id}
+func (c *callbackTag) RuleDelete() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := c.tagRuleService.DeleteRule(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, "Правило удалено")
+
+		return c.sendRuleList(ctx, update)
+	}
+}
+
+// QuestionTags - q_tags:{id}
+func (c *callbackTag) QuestionTags() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		return c.sendQuestionTags(ctx, update, id)
+	}
+}
+
+// QuestionTagToggle - qtag:{question_id}:{tag_id}
+func (c *callbackTag) QuestionTagToggle() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		data := action.FromContext(ctx)
+
+		questionID, err := data.Int(0)
+		if err != nil {
+			return err
+		}
+		tagID, err := data.Int(1)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.tagService.ToggleQuestionTag(ctx, update.CallbackQuery.From.ID, questionID, tagID); err != nil {
+			return err
+		}
+
+		return c.sendQuestionTags(ctx, update, questionID)
+	}
+}
+
+// QuestionFilterList - q_list:{tag_id} - новые вопросы, q_top:{tag_id} - популярные. tag_id = 0 - все вопросы
+func (c *callbackTag) QuestionFilterList() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		sort := entity.SortLatest
+		if action.FromContext(ctx).Action == "q_top" {
+			sort = entity.SortPopular
+		}
+
+		tagID, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		tags, err := c.tagService.GetAllTags(ctx)
+		if err != nil {
+			c.log.Error("tagService.GetAllTags: %v", err)
+			return customErr.ErrServerError
+		}
+
+		questions, err := c.questionService.GetList(ctx, tagID, sort)
+		if err != nil {
+			c.log.Error("questionService.GetList: %v", err)
+			return customErr.ErrServerError
+		}
+
+		text := "Последние вопросы"
+		if sort == entity.SortPopular {
+			text = "Популярные вопросы"
+		}
+		if tag := findTag(tags, tagID); tag != nil {
+			text += " с тегом #" + tag.Name
+		}
+		if len(questions) == 0 {
+			text += "\n\nВопросов нет"
+		}
+
+		listMarkup := markup.QuestionFilterList(tagID, sort == entity.SortPopular, tagButtons(tags), questionButtons(questions))
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&listMarkup,
+			text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (c *callbackTag) sendTagSetting(ctx context.Context, update *tgbotapi.Update) error {
+	tags, err := c.tagService.GetAllTags(ctx)
+	if err != nil {
+		c.log.Error("tagService.GetAllTags: %v", err)
+		return customErr.ErrServerError
+	}
+
+	text := "Словарь тегов. Нажмите на тег, чтобы удалить его"
+	if len(tags) == 0 {
+		text = "Словарь тегов пуст"
+	}
+
+	tagMarkup := markup.TagSetting(tagButtons(tags))
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&tagMarkup,
+		text); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *callbackTag) sendRuleList(ctx context.Context, update *tgbotapi.Update) error {
+	rules, stats, err := c.tagRuleService.GetRules(ctx)
+	if err != nil {
+		c.log.Error("tagRuleService.GetRules: %v", err)
+		return customErr.ErrServerError
+	}
+
+	statsByRule := make(map[int]entity.TagRuleStats, len(stats))
+	for _, st := range stats {
+		statsByRule[st.RuleID] = st
+	}
+
+	var (
+		sb      strings.Builder
+		buttons = make([][2]string, 0, len(rules))
+	)
+	sb.WriteString("<b>Правила разметки</b>\nПроверяются по порядку, тег ставит первое сработавшее правило\n")
+	if len(rules) == 0 {
+		sb.WriteString("\nПравил пока нет")
+	}
+
+	for i, rule := range rules {
+		st := statsByRule[rule.ID]
+		sb.WriteString(fmt.Sprintf("\n%d. #%s, %s, вес %.2f: %s\n   сработало %d, снято вручную %d, тег добавлен вручную %d\n",
+			i+1,
+			html.EscapeString(rule.TagName),
+			rule.Kind,
+			rule.Weight,
+			html.EscapeString(strings.Join(rule.Patterns, "; ")),
+			st.Assigned, st.Removed, st.Missed))
+
+		buttons = append(buttons, [2]string{strconv.Itoa(rule.ID), fmt.Sprintf("%d. #%s", i+1, rule.TagName)})
+	}
+
+	ruleMarkup := markup.TagRules(buttons)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&ruleMarkup,
+		sb.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *callbackTag) sendQuestionTags(ctx context.Context, update *tgbotapi.Update, questionID int) error {
+	tags, err := c.tagService.GetAllTags(ctx)
+	if err != nil {
+		c.log.Error("tagService.GetAllTags: %v", err)
+		return customErr.ErrServerError
+	}
+
+	questionTags, err := c.tagService.GetQuestionTags(ctx, questionID)
+	if err != nil {
+		c.log.Error("tagService.GetQuestionTags: %v", err)
+		return customErr.ErrServerError
+	}
+
+	selected := make(map[string]bool, len(questionTags))
+	for _, tag := range questionTags {
+		selected[strconv.Itoa(tag.ID)] = true
+	}
+
+	text := fmt.Sprintf("Теги вопроса #%d", questionID)
+	if len(tags) == 0 {
+		text = "Словарь тегов пуст, добавьте теги в панели управления"
+	}
+
+	tagMarkup := markup.QuestionTags(questionID, tagButtons(tags), selected)
+	if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+		update.CallbackQuery.Message.MessageID,
+		&tagMarkup,
+		text); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func tagButtons(tags []entity.Tag) [][2]string {
+	buttons := make([][2]string, 0, len(tags))
+	for _, tag := range tags {
+		buttons = append(buttons, [2]string{strconv.Itoa(tag.ID), tag.Name})
+	}
+	return buttons
+}
+
+func findTag(tags []entity.Tag, id int) *entity.Tag {
+	for i := range tags {
+		if tags[i].ID == id {
+			return &tags[i]
+		}
+	}
+	return nil
+}
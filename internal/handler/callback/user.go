@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
 	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
 	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
@@ -12,41 +15,60 @@ import (
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
 	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
 	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"sync"
+	"strings"
 )
 
+const success = "Операция выполнена успешно. "
+
 type CallbackUser interface {
 	AdminRoleSetting() tgbot.ViewFunc
 	AdminLookUp() tgbot.ViewFunc
 	AdminDeleteRole() tgbot.ViewFunc
 	AdminSetRole() tgbot.ViewFunc
+	AdminPickRole() tgbot.ViewFunc
+	AdminCreateDialog() dialog.Dialog
+	AdminDeleteDialog() dialog.Dialog
 	MainMenu() tgbot.ViewFunc
 	QuestionSettings() tgbot.ViewFunc
+	QuestionExport() tgbot.ViewFunc
 }
 
+// questionFileName - имя выгрузки вопросов в Telegram, сам файл создается во временном каталоге
+const questionFileName = "question_result.xlsx"
+
 type callbackUser struct {
-	userService service.UserService
-	log         *logger.Logger
-	store       store.LocalStorage
-	tgMsg       customMsg.Message
-	pg          *postgres.Postgres
-	excel       *excel.Excel
-
-	mu sync.RWMutex
+	userService       service.UserService
+	auditService      service.AuditService
+	permissionService service.PermissionService
+	tagService        service.TagService
+	log               *logger.Logger
+	dialogs           *dialog.Engine
+	commands          *tgbot.Bot
+	tgMsg             customMsg.Message
+	pg                *postgres.Postgres
+	excel             *excel.Excel
 }
 
 func NewCallbackUser(
 	userService service.UserService,
+	auditService service.AuditService,
+	permissionService service.PermissionService,
+	tagService service.TagService,
 	log *logger.Logger,
-	store store.LocalStorage,
+	dialogs *dialog.Engine,
+	commands *tgbot.Bot,
 	tgMsg customMsg.Message,
 	pg *postgres.Postgres,
 	excel *excel.Excel,
 ) (CallbackUser, error) {
-	if store == nil {
-		return nil, errors.New("store is nil")
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
+	if commands == nil {
+		return nil, errors.New("commands is nil")
 	}
 	if log == nil {
 		return nil, errors.New("logger is nil")
@@ -54,6 +76,15 @@ func NewCallbackUser(
 	if userService == nil {
 		return nil, errors.New("userService is nil")
 	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if permissionService == nil {
+		return nil, errors.New("permissionService is nil")
+	}
+	if tagService == nil {
+		return nil, errors.New("tagService is nil")
+	}
 	if tgMsg == nil {
 		return nil, errors.New("tgMsg is nil")
 	}
@@ -65,12 +96,16 @@ func NewCallbackUser(
 	}
 
 	return &callbackUser{
-		userService: userService,
-		log:         log,
-		store:       store,
-		tgMsg:       tgMsg,
-		pg:          pg,
-		excel:       excel,
+		userService:       userService,
+		auditService:      auditService,
+		permissionService: permissionService,
+		tagService:        tagService,
+		log:               log,
+		dialogs:           dialogs,
+		commands:          commands,
+		tgMsg:             tgMsg,
+		pg:                pg,
+		excel:             excel,
 	}, nil
 }
 
@@ -79,7 +114,7 @@ func (c *callbackUser) AdminRoleSetting() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
 		text := "Управление администраторами"
 
-		if _, err := c.tgMsg.SendEditMessage(update.CallbackQuery.Message.Chat.ID,
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
 			update.CallbackQuery.Message.MessageID,
 			&markup.UserSetting,
 			text); err != nil {
@@ -105,7 +140,7 @@ func (c *callbackUser) AdminLookUp() tgbot.ViewFunc {
 			return customErr.ErrServerError
 		}
 
-		if _, err := c.tgMsg.SendEditMessage(update.CallbackQuery.Message.Chat.ID,
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
 			update.CallbackQuery.Message.MessageID,
 			&markup.MainMenu,
 			string(adminByte)); err != nil {
@@ -119,51 +154,125 @@ func (c *callbackUser) AdminLookUp() tgbot.ViewFunc {
 // AdminDeleteRole - admin_delete_role
 func (c *callbackUser) AdminDeleteRole() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
-		text := "Напишите никнейм пользователя, у которого вы хотите отозвать права администратором.\nДля отмены команды" +
-			"отправьте /cancel"
+		return c.dialogs.Start(ctx, update, store.AdminDelete, roleDialog{Role: entity.UserType})
+	}
+}
 
-		msgID, err := c.tgMsg.SendNewMessage(update.CallbackQuery.Message.Chat.ID, nil, text)
-		if err != nil {
-			return err
+// AdminSetRole - admin_set_role
+func (c *callbackUser) AdminSetRole() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		roles := make([][2]string, 0, len(entity.AssignableRoles))
+		for _, role := range entity.AssignableRoles {
+			roles = append(roles, [2]string{string(role), role.Title()})
 		}
 
-		c.store.Set(&store.Data{
-			OperationType: store.AdminDelete,
-			CurrentMsgID:  msgID,
-			PreferMsgID:   update.CallbackQuery.Message.MessageID,
-		}, update.CallbackQuery.Message.Chat.ID)
+		roleMarkup := markup.RolePick(roles)
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&roleMarkup,
+			"Выберите роль, которую хотите назначить"); err != nil {
+			return err
+		}
 
 		return nil
 	}
 }
 
-// AdminSetRole - admin_set_role
-func (c *callbackUser) AdminSetRole() tgbot.ViewFunc {
+// AdminPickRole - role_pick:{role}
+func (c *callbackUser) AdminPickRole() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
-		text := "Напишите никнейм пользователя, которого вы хотите назначить администратором.\nДля отмены команды " +
-			"отправьте /cancel"
-
-		msgID, err := c.tgMsg.SendNewMessage(update.CallbackQuery.Message.Chat.ID, nil, text)
+		arg, err := action.FromContext(ctx).String(0)
 		if err != nil {
 			return err
 		}
 
-		c.store.Set(&store.Data{
-			OperationType: store.AdminCreate,
-			CurrentMsgID:  msgID,
-			PreferMsgID:   update.CallbackQuery.Message.MessageID,
-		}, update.CallbackQuery.Message.Chat.ID)
+		role := entity.UserRole(arg)
+		if !role.IsValid() || role == entity.UserType || role == entity.SuperAdminType {
+			return customErr.ErrInvalidRequest
+		}
 
-		return nil
+		return c.dialogs.Start(ctx, update, store.AdminCreate, roleDialog{Role: role})
 	}
 }
 
+// roleDialog - данные диалогов назначения и отзыва роли, при отзыве Role = user
+type roleDialog struct {
+	Role     entity.UserRole `json:"role"`
+	Username string          `json:"username"`
+}
+
+// AdminCreateDialog - назначение выбранной роли по никнейму
+func (c *callbackUser) AdminCreateDialog() dialog.Dialog {
+	return &dialog.Flow[roleDialog]{
+		Name: store.AdminCreate,
+		Steps: []dialog.Step[roleDialog]{{
+			Prompt: func(data *roleDialog) string {
+				return fmt.Sprintf("Напишите никнейм пользователя, которому вы хотите назначить роль «%s».", data.Role.Title())
+			},
+			Apply: applyUsername,
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *roleDialog) (*dialog.Reply, error) {
+			user, err := c.userService.UpdateRoleByUsername(ctx, update.Message.From.ID, data.Role, data.Username)
+			if err != nil {
+				return nil, err
+			}
+			c.commands.PublishUserCommands(ctx, user.ID)
+
+			return &dialog.Reply{
+				Text:    fmt.Sprintf("%sПользователь получил роль «%s».", success, data.Role.Title()),
+				Markup:  &markup.UserSetting,
+				Cleanup: true,
+			}, nil
+		},
+	}
+}
+
+// AdminDeleteDialog - отзыв роли по никнейму
+func (c *callbackUser) AdminDeleteDialog() dialog.Dialog {
+	return &dialog.Flow[roleDialog]{
+		Name: store.AdminDelete,
+		Steps: []dialog.Step[roleDialog]{{
+			Prompt: func(data *roleDialog) string {
+				return "Напишите никнейм пользователя, у которого вы хотите отозвать роль."
+			},
+			Apply: applyUsername,
+		}},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *roleDialog) (*dialog.Reply, error) {
+			user, err := c.userService.UpdateRoleByUsername(ctx, update.Message.From.ID, entity.UserType, data.Username)
+			if err != nil {
+				return nil, err
+			}
+			c.commands.PublishUserCommands(ctx, user.ID)
+
+			return &dialog.Reply{
+				Text:    success + "Пользователь лишился роли в панели управления.",
+				Markup:  &markup.UserSetting,
+				Cleanup: true,
+			}, nil
+		},
+	}
+}
+
+func applyUsername(ctx context.Context, data *roleDialog, text string) error {
+	username := strings.TrimSpace(text)
+	if username == "" || strings.ContainsAny(username, " \n") {
+		return dialog.InputError("Никнейм пишется одним словом, например: ivanov")
+	}
+	data.Username = username
+	return nil
+}
+
 func (c *callbackUser) MainMenu() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		permissions, err := c.permissionService.GetUserPermissions(ctx, update.CallbackQuery.From.ID)
+		if err != nil {
+			return err
+		}
 
-		if _, err := c.tgMsg.SendEditMessage(update.FromChat().ID,
+		startMenu := markup.StartMenu.For(permissions.Allowed)
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.FromChat().ID,
 			update.CallbackQuery.Message.MessageID,
-			&markup.StartMenu,
+			&startMenu,
 			"Панель управления"); err != nil {
 			return err
 		}
@@ -174,7 +283,44 @@ func (c *callbackUser) MainMenu() tgbot.ViewFunc {
 
 func (c *callbackUser) QuestionSettings() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
-		rows, err := c.pg.Pool.Query(ctx, `SELECT id,user_id,question from question `)
+		tags, err := c.tagService.GetAllTags(ctx)
+		if err != nil {
+			c.log.Error("tagService.GetAllTags: %v", err)
+			return customErr.ErrServerError
+		}
+
+		exportMarkup := markup.QuestionExport(tagButtons(tags))
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			&exportMarkup,
+			"Какие вопросы выгрузить?"); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// QuestionExport - q_export:{tag_id}, tag_id = 0 - все вопросы
+func (c *callbackUser) QuestionExport() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		tagID, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		rows, err := c.pg.Pool.Query(ctx, `SELECT q.id, q.user_id, q.question, q.status,
+				coalesce(string_agg(t.name, ', ' order by t.name), ''),
+				(select count(*) from question_vote v where v.question_id = q.id),
+				(select coalesce(string_agg(case when th.kind = 'followup' then 'Автор: ' else 'Ответ: ' end || th.text,
+					E'\n' order by th.created_at, th.id), '')
+					from question_thread th where th.question_id = q.id)
+			from question q
+			left join question_tag qt on qt.question_id = q.id
+			left join tag t on t.id = qt.tag_id
+			where $1 = 0 or exists (select 1 from question_tag f where f.question_id = q.id and f.tag_id = $1)
+			group by q.id
+			order by q.id`, tagID)
 		if err != nil {
 			return err
 		}
@@ -183,7 +329,7 @@ func (c *callbackUser) QuestionSettings() tgbot.ViewFunc {
 		var results []excel.Question
 		for rows.Next() {
 			var result excel.Question
-			err := rows.Scan(&result.ID, &result.UserID, &result.Question)
+			err := rows.Scan(&result.ID, &result.UserID, &result.Question, &result.Status, &result.Tags, &result.Votes, &result.Thread)
 			if err != nil {
 				return err
 			}
@@ -193,33 +339,37 @@ func (c *callbackUser) QuestionSettings() tgbot.ViewFunc {
 			return err
 		}
 
-		c.mu.Lock()
 		fileName, err := c.excel.GenerateUserResultsExcelFile(results, update.CallbackQuery.From.UserName)
 		if err != nil {
 			c.log.Error("Excel.GenerateExcelFile: failed to generate excel file: %v", err)
 			return err
 		}
+		defer c.excel.RemoveExcelFile(fileName)
 
 		fileIDBytes, err := c.excel.GetExcelFile(fileName)
 		if err != nil {
 			c.log.Error("Excel.GetExcelFile: failed to get excel file: %v", err)
 			return err
 		}
-		c.mu.Unlock()
 
 		if fileIDBytes == nil {
 			c.log.Error("fileIDBytes is nil")
 			return errors.New("ошибка в обработке файла")
 		}
 
-		if _, err := c.tgMsg.SendDocument(update.FromChat().ID,
-			fileName,
+		if _, err := c.tgMsg.SendDocument(ctx, update.FromChat().ID,
+			questionFileName,
 			fileIDBytes,
 			"Список вопрососов",
 		); err != nil {
 			return err
 		}
 
+		c.auditService.Log(ctx, update.CallbackQuery.From.ID, entity.AuditQuestionExport, questionFileName, entity.AuditDiff{
+			"questions": {New: len(results)},
+			"tag_id":    {New: tagID},
+		})
+
 		return nil
 	}
 }
@@ -0,0 +1,139 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"strings"
+)
+
+type CallbackVote interface {
+	// VoteView - /vote
+	VoteView() tgbot.ViewFunc
+	VoteUp() tgbot.ViewFunc
+	VoteMore() tgbot.ViewFunc
+}
+
+type callbackVote struct {
+	voteService service.VoteService
+	log         *logger.Logger
+	tgMsg       customMsg.Message
+}
+
+func NewCallbackVote(
+	voteService service.VoteService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (CallbackVote, error) {
+	if voteService == nil {
+		return nil, errors.New("voteService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &callbackVote{
+		voteService: voteService,
+		log:         log,
+		tgMsg:       tgMsg,
+	}, nil
+}
+
+func (c *callbackVote) VoteView() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		text, ballotMarkup, err := c.ballot(ctx, update.Message.From.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, ballotMarkup, text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// VoteUp - vote_up:{question_id}
+func (c *callbackVote) VoteUp() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		id, err := callbackID(ctx, 0)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.voteService.Vote(ctx, update.CallbackQuery.From.ID, id); err != nil {
+			return err
+		}
+		tgbot.Toast(ctx, "Голос учтен")
+
+		if update.CallbackQuery.Message.ReplyMarkup == nil {
+			return nil
+		}
+
+		// текст бюллетеня не меняется, обновляются только кнопки
+		voted := markup.MarkVoted(*update.CallbackQuery.Message.ReplyMarkup, update.CallbackData())
+		if err := c.tgMsg.SendEditMarkup(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			voted); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// VoteMore - vote_more
+func (c *callbackVote) VoteMore() tgbot.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		text, ballotMarkup, err := c.ballot(ctx, update.CallbackQuery.From.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := c.tgMsg.SendEditMessage(ctx, update.CallbackQuery.Message.Chat.ID,
+			update.CallbackQuery.Message.MessageID,
+			ballotMarkup,
+			text); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func (c *callbackVote) ballot(ctx context.Context, userID int64) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	questions, err := c.voteService.GetBallot(ctx, userID)
+	if err != nil {
+		c.log.Error("voteService.GetBallot: %v", err)
+		return "", nil, err
+	}
+
+	if len(questions) == 0 {
+		return "Сейчас нет новых вопросов для голосования, загляните позже", nil, nil
+	}
+
+	var (
+		sb  strings.Builder
+		ids = make([]string, 0, len(questions))
+	)
+	sb.WriteString("Отметьте вопросы, на которые аналитикам стоит ответить в первую очередь:\n")
+	for i, q := range questions {
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n", i+1, html.EscapeString(shorten(q.Question, 300))))
+		ids = append(ids, strconv.Itoa(q.ID))
+	}
+
+	ballotMarkup := markup.VoteBallot(ids)
+	return sb.String(), &ballotMarkup, nil
+}
@@ -0,0 +1,330 @@
+package dialog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	cancelText  = "Действие отменено"
+	expiredText = "Время ожидания истекло, действие отменено"
+)
+
+// Engine - ведет пользователей по шагам зарегистрированных сценариев. Состояние хранится в store
+// по id пользователя, поэтому диалог в группе ведет тот администратор, который его начал
+type Engine struct {
+	store   store.LocalStorage
+	tgMsg   customMsg.Message
+	log     *logger.Logger
+	timeout time.Duration
+
+	dialogs map[store.TypeCommand]Dialog
+
+	mu     sync.Mutex
+	timers map[int64]*time.Timer
+}
+
+func NewEngine(storage store.LocalStorage, tgMsg customMsg.Message, log *logger.Logger, timeout time.Duration) (*Engine, error) {
+	if storage == nil {
+		return nil, errors.New("storage is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &Engine{
+		store:   storage,
+		tgMsg:   tgMsg,
+		log:     log,
+		timeout: timeout,
+		dialogs: make(map[store.TypeCommand]Dialog),
+		timers:  make(map[int64]*time.Timer),
+	}, nil
+}
+
+func (e *Engine) Register(dialog Dialog) {
+	e.dialogs[dialog.name()] = dialog
+}
+
+// Start - начинает сценарий name по нажатию кнопки, data - начальные данные сценария.
+// Незавершенный диалог пользователя заменяется новым
+func (e *Engine) Start(ctx context.Context, update *tgbotapi.Update, name store.TypeCommand, data any) error {
+	dialog, ok := e.dialogs[name]
+	if !ok {
+		return fmt.Errorf("dialog %s is not registered", name)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := update.CallbackQuery
+	if previous, ok := e.store.Read(query.From.ID); ok {
+		e.removeKeyboard(ctx, previous.ChatID, previous.CurrentMsgID)
+	}
+
+	return e.prompt(ctx, query.From.ID, &store.Data{
+		OperationType: name,
+		Payload:       payload,
+		ChatID:        query.Message.Chat.ID,
+		PreferMsgID:   query.Message.MessageID,
+	}, dialog, "")
+}
+
+// Handle - передает сообщение текущему шагу диалога. false, если у пользователя нет диалога в этом чате.
+// Другая команда прерывает диалог и обрабатывается как обычно
+func (e *Engine) Handle(ctx context.Context, update *tgbotapi.Update) (bool, error) {
+	message := update.Message
+	userID := message.From.ID
+
+	state, ok := e.store.Read(userID)
+	if !ok || state.ChatID != message.Chat.ID {
+		return false, nil
+	}
+
+	dialog, ok := e.dialogs[state.OperationType]
+	if !ok {
+		e.finish(userID)
+		return false, nil
+	}
+
+	if time.Since(state.UpdatedAt) > e.timeout {
+		e.finish(userID)
+		e.closePrompt(ctx, state, expiredText)
+		return true, nil
+	}
+
+	switch message.Command() {
+	case "":
+	case "cancel":
+		e.cancel(ctx, userID, state)
+		return true, nil
+	case "back":
+		return true, e.back(ctx, userID, state, dialog)
+	default:
+		e.cancel(ctx, userID, state)
+		return false, nil
+	}
+
+	payload, err := dialog.apply(ctx, state.Payload, state.Step, message.Text)
+	if err != nil {
+		var inputErr InputError
+		if errors.As(err, &inputErr) {
+			state.MessageIDs = append(state.MessageIDs, message.MessageID)
+			return true, e.prompt(ctx, userID, state, dialog, "⚠️ "+inputErr.Error()+"\n\n")
+		}
+
+		e.finish(userID)
+		e.removeKeyboard(ctx, state.ChatID, state.CurrentMsgID)
+		return true, err
+	}
+
+	state.Payload = payload
+	state.MessageIDs = append(state.MessageIDs, message.MessageID)
+	state.Step++
+	if state.Step < dialog.stepCount() {
+		return true, e.prompt(ctx, userID, state, dialog, "")
+	}
+
+	e.finish(userID)
+	reply, err := dialog.done(ctx, update, payload)
+	if err != nil || reply == nil {
+		e.removeKeyboard(ctx, state.ChatID, state.CurrentMsgID)
+		return true, err
+	}
+
+	if reply.Cleanup {
+		for _, messageID := range append(state.MessageIDs, state.PreferMsgID) {
+			e.deleteMessage(ctx, state.ChatID, messageID)
+		}
+	}
+	if _, err := e.tgMsg.SendEditMessage(ctx, state.ChatID, state.CurrentMsgID, reply.Markup, reply.Text); err != nil {
+		e.log.Error("failed to send dialog reply: %v", err)
+	}
+
+	return true, nil
+}
+
+// Cancel - /cancel и кнопка «Отмена»
+func (e *Engine) Cancel() func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		userID := update.SentFrom().ID
+
+		state, ok := e.current(ctx, update)
+		if !ok {
+			if update.Message != nil {
+				_, err := e.tgMsg.SendNewMessage(ctx, update.Message.Chat.ID, nil, "Нет действия, которое можно отменить")
+				return err
+			}
+			return nil
+		}
+
+		e.cancel(ctx, userID, state)
+		return nil
+	}
+}
+
+// Back - /back и кнопка «Назад»
+func (e *Engine) Back() func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		state, ok := e.current(ctx, update)
+		if !ok {
+			return nil
+		}
+
+		dialog, ok := e.dialogs[state.OperationType]
+		if !ok {
+			return nil
+		}
+
+		return e.back(ctx, update.SentFrom().ID, state, dialog)
+	}
+}
+
+// Close - останавливает таймеры бездействия, состояние диалогов остается в store
+func (e *Engine) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for userID, timer := range e.timers {
+		timer.Stop()
+		delete(e.timers, userID)
+	}
+}
+
+// current - диалог пользователя. Кнопка под старым приглашением только теряет клавиатуру
+func (e *Engine) current(ctx context.Context, update *tgbotapi.Update) (*store.Data, bool) {
+	state, ok := e.store.Read(update.SentFrom().ID)
+
+	if query := update.CallbackQuery; query != nil {
+		if !ok || state.CurrentMsgID != query.Message.MessageID {
+			e.removeKeyboard(ctx, query.Message.Chat.ID, query.Message.MessageID)
+			return nil, false
+		}
+	}
+
+	return state, ok
+}
+
+func (e *Engine) back(ctx context.Context, userID int64, state *store.Data, dialog Dialog) error {
+	if state.Step == 0 {
+		e.cancel(ctx, userID, state)
+		return nil
+	}
+
+	state.Step--
+	return e.prompt(ctx, userID, state, dialog, "")
+}
+
+func (e *Engine) cancel(ctx context.Context, userID int64, state *store.Data) {
+	e.finish(userID)
+	e.closePrompt(ctx, state, cancelText)
+}
+
+// prompt - отправляет приглашение текущего шага, предыдущее приглашение теряет кнопки
+func (e *Engine) prompt(ctx context.Context, userID int64, state *store.Data, dialog Dialog, notice string) error {
+	text, err := dialog.prompt(state.Payload, state.Step)
+	if err != nil {
+		e.finish(userID)
+		return err
+	}
+
+	if state.CurrentMsgID != 0 {
+		e.removeKeyboard(ctx, state.ChatID, state.CurrentMsgID)
+		state.MessageIDs = append(state.MessageIDs, state.CurrentMsgID)
+	}
+
+	hint := "\n\nДля отмены отправьте /cancel"
+	if state.Step > 0 {
+		hint += ", для возврата к предыдущему шагу — /back"
+	}
+
+	promptMarkup := markup.DialogPrompt(state.Step > 0)
+	msgID, err := e.tgMsg.SendNewMessage(ctx, state.ChatID, &promptMarkup, notice+text+hint)
+	if err != nil {
+		e.finish(userID)
+		return err
+	}
+	state.CurrentMsgID = msgID
+
+	e.save(userID, state)
+	return nil
+}
+
+func (e *Engine) save(userID int64, state *store.Data) {
+	state.UpdatedAt = time.Now()
+	e.store.Set(state, userID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if timer, ok := e.timers[userID]; ok {
+		timer.Stop()
+	}
+	updatedAt := state.UpdatedAt
+	e.timers[userID] = time.AfterFunc(e.timeout, func() {
+		e.expire(userID, updatedAt)
+	})
+}
+
+func (e *Engine) finish(userID int64) {
+	e.store.Delete(userID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if timer, ok := e.timers[userID]; ok {
+		timer.Stop()
+		delete(e.timers, userID)
+	}
+}
+
+// expire - диалог не продолжали timeout. Если за это время он сменился, ничего не происходит
+func (e *Engine) expire(userID int64, updatedAt time.Time) {
+	// таймер работает в своей горутине, паника здесь остановила бы весь бот
+	defer func() {
+		if p := recover(); p != nil {
+			e.log.Error("panic recovered in dialog expiry of user %d: %v, %s", userID, p, string(debug.Stack()))
+		}
+	}()
+
+	state, ok := e.store.Read(userID)
+	if !ok || !state.UpdatedAt.Equal(updatedAt) {
+		return
+	}
+
+	e.finish(userID)
+	e.closePrompt(context.Background(), state, expiredText)
+	e.log.Info("Dialog %s of user %d expired", state.OperationType, userID)
+}
+
+// closePrompt - заменяет приглашение к вводу текстом, клавиатура при этом убирается
+func (e *Engine) closePrompt(ctx context.Context, state *store.Data, text string) {
+	if _, err := e.tgMsg.SendEditMessage(ctx, state.ChatID, state.CurrentMsgID, nil, text); err != nil {
+		e.log.Error("failed to close dialog prompt %d: %v", state.CurrentMsgID, err)
+	}
+}
+
+// removeKeyboard, deleteMessage - ошибку уже записал tgMsg, диалог продолжается без них
+func (e *Engine) removeKeyboard(ctx context.Context, chatID int64, messageID int) {
+	emptyMarkup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	_ = e.tgMsg.SendEditMarkup(ctx, chatID, messageID, emptyMarkup)
+}
+
+func (e *Engine) deleteMessage(ctx context.Context, chatID int64, messageID int) {
+	_ = e.tgMsg.DeleteMessage(ctx, chatID, messageID)
+}
@@ -0,0 +1,270 @@
+package dialog
+
+import (
+	"context"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUserID = 100
+	testChatID = 100
+	testDialog = store.TypeCommand("test")
+)
+
+// fakeMsg - записывает тексты отправленных и отредактированных сообщений
+type fakeMsg struct {
+	mu     sync.Mutex
+	nextID int
+	sent   []string
+	edited map[int]string
+}
+
+func (f *fakeMsg) SendNewMessage(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	f.sent = append(f.sent, text)
+	return f.nextID, nil
+}
+
+func (f *fakeMsg) SendEditMessage(ctx context.Context, chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.edited[messageID] = text
+	return messageID, nil
+}
+
+func (f *fakeMsg) SendDocument(ctx context.Context, chatID int64, fileName string, fileIDBytes *[]byte, text string) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeMsg) SendEditMarkup(ctx context.Context, chatID int64, messageID int, markup tgbotapi.InlineKeyboardMarkup) error {
+	return nil
+}
+
+func (f *fakeMsg) DeleteMessage(ctx context.Context, chatID int64, messageID int) error {
+	return nil
+}
+
+func (f *fakeMsg) SetCommands(ctx context.Context, scope tgbotapi.BotCommandScope, commands []tgbotapi.BotCommand) error {
+	return nil
+}
+
+func (f *fakeMsg) DeleteCommands(ctx context.Context, scope tgbotapi.BotCommandScope) error {
+	return nil
+}
+
+func (f *fakeMsg) editedText(messageID int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.edited[messageID]
+}
+
+type testData struct {
+	Name string `json:"name"`
+	Age  string `json:"age"`
+}
+
+func newTestEngine(t *testing.T, timeout time.Duration) (*Engine, *fakeMsg, *store.Store) {
+	t.Helper()
+
+	msg := &fakeMsg{edited: make(map[int]string)}
+	storage := store.NewStore(0)
+
+	e, err := NewEngine(storage, msg, logger.New(), timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Close)
+
+	e.Register(&Flow[testData]{
+		Name: testDialog,
+		Steps: []Step[testData]{
+			{
+				Prompt: func(data *testData) string { return "name?" },
+				Apply: func(ctx context.Context, data *testData, text string) error {
+					data.Name = text
+					return nil
+				},
+			},
+			{
+				Prompt: func(data *testData) string { return "age?" },
+				Apply: func(ctx context.Context, data *testData, text string) error {
+					data.Age = text
+					return nil
+				},
+			},
+		},
+		Done: func(ctx context.Context, update *tgbotapi.Update, data *testData) (*Reply, error) {
+			return &Reply{Text: "done " + data.Name + " " + data.Age}, nil
+		},
+	})
+
+	return e, msg, storage
+}
+
+func startTestDialog(t *testing.T, e *Engine) {
+	t.Helper()
+
+	update := &tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		From:    &tgbotapi.User{ID: testUserID},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: testChatID}},
+	}}
+	if err := e.Start(context.Background(), update, testDialog, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func textUpdate(text string) *tgbotapi.Update {
+	message := &tgbotapi.Message{
+		MessageID: 50,
+		From:      &tgbotapi.User{ID: testUserID},
+		Chat:      &tgbotapi.Chat{ID: testChatID, Type: "private"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	return &tgbotapi.Update{Message: message}
+}
+
+func TestEngineCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		// input - сообщения после начала диалога, последнее его прерывает
+		input []string
+		// handled - результат Handle для последнего сообщения
+		handled bool
+		// prompt - id приглашения, которое должно смениться на cancelText
+		prompt int
+	}{
+		{name: "cancel command", input: []string{"/cancel"}, handled: true, prompt: 1},
+		{name: "back on first step", input: []string{"/back"}, handled: true, prompt: 1},
+		{name: "cancel on second step", input: []string{"Иван", "/cancel"}, handled: true, prompt: 2},
+		{name: "other command is handled as usual", input: []string{"/start"}, handled: false, prompt: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, msg, storage := newTestEngine(t, time.Hour)
+			startTestDialog(t, e)
+
+			var handled bool
+			for _, text := range tt.input {
+				var err error
+				if handled, err = e.Handle(context.Background(), textUpdate(text)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if handled != tt.handled {
+				t.Fatalf("Handle = %v, want %v", handled, tt.handled)
+			}
+			if _, ok := storage.Read(testUserID); ok {
+				t.Fatal("dialog state is kept after cancel")
+			}
+			if got := msg.editedText(tt.prompt); got != cancelText {
+				t.Fatalf("prompt %d = %q, want %q", tt.prompt, got, cancelText)
+			}
+		})
+	}
+}
+
+func TestEngineBackReturnsToPreviousStep(t *testing.T) {
+	e, msg, storage := newTestEngine(t, time.Hour)
+	startTestDialog(t, e)
+
+	for _, text := range []string{"Иван", "/back"} {
+		if _, err := e.Handle(context.Background(), textUpdate(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, ok := storage.Read(testUserID)
+	if !ok || state.Step != 0 {
+		t.Fatalf("state after /back = %+v, %v, want step 0", state, ok)
+	}
+	if last := msg.sent[len(msg.sent)-1]; !strings.HasPrefix(last, "name?") {
+		t.Fatalf("last prompt = %q, want the first step", last)
+	}
+}
+
+func TestEngineCancelWithoutDialog(t *testing.T) {
+	e, msg, _ := newTestEngine(t, time.Hour)
+
+	if err := e.Cancel()(context.Background(), nil, textUpdate("/cancel")); err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.sent) != 1 || msg.sent[0] != "Нет действия, которое можно отменить" {
+		t.Fatalf("sent = %q, want nothing to cancel notice", msg.sent)
+	}
+}
+
+func TestEngineExpiresByTimer(t *testing.T) {
+	e, msg, storage := newTestEngine(t, 20*time.Millisecond)
+	startTestDialog(t, e)
+
+	deadline := time.Now().Add(time.Second)
+	for msg.editedText(1) != expiredText {
+		if time.Now().After(deadline) {
+			t.Fatal("prompt was not closed after timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, ok := storage.Read(testUserID); ok {
+		t.Fatal("dialog state is kept after timeout")
+	}
+}
+
+func TestEngineExpiresOnLateMessage(t *testing.T) {
+	e, msg, storage := newTestEngine(t, 20*time.Millisecond)
+	startTestDialog(t, e)
+
+	// таймеры остановлены, например состояние пережило перезапуск, срок проверяется при следующем сообщении
+	e.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	handled, err := e.Handle(context.Background(), textUpdate("Иван"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !handled {
+		t.Fatal("late message to an expired dialog was passed on")
+	}
+	if got := msg.editedText(1); got != expiredText {
+		t.Fatalf("prompt = %q, want %q", got, expiredText)
+	}
+	if _, ok := storage.Read(testUserID); ok {
+		t.Fatal("dialog state is kept after timeout")
+	}
+}
+
+func TestEngineCompletesFlow(t *testing.T) {
+	e, msg, storage := newTestEngine(t, time.Hour)
+	startTestDialog(t, e)
+
+	for _, text := range []string{"Иван", "30"} {
+		handled, err := e.Handle(context.Background(), textUpdate(text))
+		if err != nil || !handled {
+			t.Fatalf("Handle(%q) = %v, %v", text, handled, err)
+		}
+	}
+
+	if got := msg.editedText(2); got != "done Иван 30" {
+		t.Fatalf("reply = %q, want done Иван 30", got)
+	}
+	if _, ok := storage.Read(testUserID); ok {
+		t.Fatal("dialog state is kept after completion")
+	}
+}
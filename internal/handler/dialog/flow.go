@@ -0,0 +1,93 @@
+package dialog
+
+import (
+	"context"
+	"encoding/json"
+	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// InputError - ввод не прошел проверку: текст показывается пользователю, шаг повторяется
+type InputError string
+
+func (e InputError) Error() string {
+	return string(e)
+}
+
+// Step - шаг сценария. Apply проверяет ввод и сохраняет его в данные сценария,
+// InputError оставляет пользователя на этом же шаге
+type Step[T any] struct {
+	Prompt func(data *T) string
+	Apply  func(ctx context.Context, data *T, text string) error
+}
+
+// Reply - итог диалога, заменяет последнее приглашение к вводу.
+// Cleanup удаляет сообщения диалога и сообщение, из которого он был начат
+type Reply struct {
+	Text    string
+	Markup  *tgbotapi.InlineKeyboardMarkup
+	Cleanup bool
+}
+
+// Flow - сценарий из шагов с данными типа T, которые сохраняются между сообщениями.
+// Done вызывается после последнего шага, nil Reply означает, что ответ Done отправил сам
+type Flow[T any] struct {
+	Name  store.TypeCommand
+	Steps []Step[T]
+	Done  func(ctx context.Context, update *tgbotapi.Update, data *T) (*Reply, error)
+}
+
+// Dialog - сценарий, который можно зарегистрировать в Engine
+type Dialog interface {
+	name() store.TypeCommand
+	stepCount() int
+	prompt(payload json.RawMessage, step int) (string, error)
+	apply(ctx context.Context, payload json.RawMessage, step int, text string) (json.RawMessage, error)
+	done(ctx context.Context, update *tgbotapi.Update, payload json.RawMessage) (*Reply, error)
+}
+
+func (f *Flow[T]) name() store.TypeCommand {
+	return f.Name
+}
+
+func (f *Flow[T]) stepCount() int {
+	return len(f.Steps)
+}
+
+func (f *Flow[T]) decode(payload json.RawMessage) (*T, error) {
+	data := new(T)
+	if len(payload) == 0 {
+		return data, nil
+	}
+	if err := json.Unmarshal(payload, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (f *Flow[T]) prompt(payload json.RawMessage, step int) (string, error) {
+	data, err := f.decode(payload)
+	if err != nil {
+		return "", err
+	}
+	return f.Steps[step].Prompt(data), nil
+}
+
+func (f *Flow[T]) apply(ctx context.Context, payload json.RawMessage, step int, text string) (json.RawMessage, error) {
+	data, err := f.decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Steps[step].Apply(ctx, data, text); err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+func (f *Flow[T]) done(ctx context.Context, update *tgbotapi.Update, payload json.RawMessage) (*Reply, error) {
+	data, err := f.decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	return f.Done(ctx, update, data)
+}
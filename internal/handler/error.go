@@ -1,20 +1,23 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"log"
+	"html"
 )
 
-func HandleError(bot *tgbotapi.BotAPI, update *tgbotapi.Update, err error) {
-	msg := tgbotapi.NewMessage(update.FromChat().ID, processError(err))
-	if _, err = bot.Send(msg); err != nil {
-		log.Printf("failed to send message: %v\n", err)
-	}
+// HandleError - сообщает пользователю об ошибке, ошибку отправки tgMsg пишет в лог сам
+func HandleError(ctx context.Context, tgMsg customMsg.Message, update *tgbotapi.Update, err error) {
+	_, _ = tgMsg.SendNewMessage(ctx, update.FromChat().ID, nil, html.EscapeString(ErrorText(err)))
 }
 
-func processError(err error) string {
-	if se, ok := err.(*customErr.BotError); ok {
+// ErrorText - текст ошибки для пользователя
+func ErrorText(err error) string {
+	var se *customErr.BotError
+	if errors.As(err, &se) {
 		return se.Msg
 	}
 	return "Неизвестная ошибка: " + err.Error()
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"time"
+)
+
+// Logging - пишет в лог автора и текст сообщения или данные кнопки, ошибку обработчика и время обработки
+func Logging(log *logger.Logger) tgbot.Middleware {
+	return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+		return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+			var username string
+			if from := update.SentFrom(); from != nil {
+				username = from.UserName
+			}
+
+			switch {
+			case update.Message != nil:
+				log.Info("[%s] %s", username, update.Message.Text)
+			case update.CallbackQuery != nil:
+				log.Info("[%s] %s", username, update.CallbackData())
+			}
+
+			start := time.Now()
+			err := next(ctx, bot, update)
+			if err != nil {
+				log.Error("failed to handle %s update of [%s] in %s: %v", tgbot.RouteName(update), username, time.Since(start), err)
+			}
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsLogInterval - как часто в лог пишется сводка по обработчикам
+const metricsLogInterval = time.Minute
+
+type routeStats struct {
+	count  int
+	errors int
+	total  time.Duration
+	max    time.Duration
+}
+
+// Metrics - число обновлений, ошибок и время обработки по обработчикам. Сводка за интервал пишется в лог
+type Metrics struct {
+	log    *logger.Logger
+	routes map[string]*routeStats
+
+	mu sync.Mutex
+}
+
+func NewMetrics(log *logger.Logger) *Metrics {
+	return &Metrics{
+		log:    log,
+		routes: make(map[string]*routeStats),
+	}
+}
+
+func (m *Metrics) Middleware() tgbot.Middleware {
+	return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+		return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+			start := time.Now()
+			err := next(ctx, bot, update)
+			m.observe(tgbot.RouteName(update), time.Since(start), err != nil)
+
+			return err
+		}
+	}
+}
+
+func (m *Metrics) observe(route string, elapsed time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.routes[route]
+	if !ok {
+		stats = new(routeStats)
+		m.routes[route] = stats
+	}
+
+	stats.count++
+	if failed {
+		stats.errors++
+	}
+	stats.total += elapsed
+	stats.max = max(stats.max, elapsed)
+}
+
+// Run - раз в metricsLogInterval пишет сводку в лог и обнуляет счетчики
+func (m *Metrics) Run(ctx context.Context) {
+	ticker := time.NewTicker(metricsLogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if summary := m.flush(); summary != "" {
+				m.log.Info("handler metrics: %s", summary)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Metrics) flush() string {
+	m.mu.Lock()
+	routes := m.routes
+	m.routes = make(map[string]*routeStats)
+	m.mu.Unlock()
+
+	names := make([]string, 0, len(routes))
+	for name := range routes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return routes[names[i]].count > routes[names[j]].count
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		stats := routes[name]
+		parts = append(parts, fmt.Sprintf("%s=%d (errors %d, avg %s, max %s)", name, stats.count, stats.errors,
+			(stats.total/time.Duration(stats.count)).Round(time.Millisecond), stats.max.Round(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
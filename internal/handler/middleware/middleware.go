@@ -2,28 +2,41 @@ package middleware
 
 import (
 	"context"
-	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
 	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
 	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func AdminMiddleware(service service.UserService, next tgbot.ViewFunc) tgbot.ViewFunc {
-	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
-		user, err := service.GetUserByID(ctx, update.FromChat().ID)
-		if err != nil {
-			if errors.Is(err, customErr.ErrNoRows) {
+// Permission - пропускает запрос, только если роль пользователя содержит permission
+func Permission(service service.PermissionService, permission entity.Permission) tgbot.Middleware {
+	return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+		return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+			from := update.SentFrom()
+			if from == nil {
 				return nil
 			}
-			return err
-		}
 
-		if user.UserRole == "admin" || user.UserRole == "superAdmin" {
-			return next(ctx, bot, update)
-		}
+			permissions, err := service.GetUserPermissions(ctx, from.ID)
+			if err != nil {
+				return err
+			}
+
+			if permissions.Has(permission) {
+				return next(ctx, bot, update)
+			}
 
-		// пользователь не админ
-		return nil
+			// на сообщения обычного пользователя бот молчит, чтобы не выдавать панель, но нажатие кнопки
+			// без ответа выглядело бы зависшим
+			if len(permissions) == 0 {
+				if update.CallbackQuery != nil {
+					tgbot.Alert(ctx, "Недостаточно прав")
+				}
+				return nil
+			}
+
+			return customErr.ErrIsNotAdmin
+		}
 	}
 }
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitText = "Слишком много запросов, попробуйте через минуту"
+	// idleUserLimit - после этого числа пользователей из памяти удаляются лимиты неактивных
+	idleUserLimit = 10000
+)
+
+// RateLimit - не больше perMinute обновлений от одного пользователя в минуту, 0 отключает ограничение.
+// На лишнее нажатие кнопки отвечает уведомлением, о лишних сообщениях предупреждает один раз
+func RateLimit(perMinute int, tgMsg customMsg.Message, log *logger.Logger) tgbot.Middleware {
+	if perMinute <= 0 {
+		return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+			return next
+		}
+	}
+
+	limiter := &userLimiter{
+		rate:  float64(perMinute) / 60,
+		burst: float64(perMinute),
+		users: make(map[int64]*userBucket),
+	}
+
+	return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+		return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+			from := update.SentFrom()
+			if from == nil {
+				return next(ctx, bot, update)
+			}
+
+			allowed, warn := limiter.allow(from.ID, time.Now())
+			if allowed {
+				return next(ctx, bot, update)
+			}
+
+			if update.CallbackQuery != nil {
+				tgbot.Toast(ctx, rateLimitText)
+				return nil
+			}
+			if warn && update.FromChat() != nil {
+				if _, err := tgMsg.SendNewMessage(ctx, update.FromChat().ID, nil, rateLimitText); err != nil {
+					log.Error("tgMsg.SendNewMessage: failed to send rate limit warning to %d: %v", update.FromChat().ID, err)
+				}
+			}
+			return nil
+		}
+	}
+}
+
+type userBucket struct {
+	tokens float64
+	last   time.Time
+	// warned - пользователь уже предупрежден, до следующего пропущенного обновления повторно не предупреждается
+	warned bool
+}
+
+type userLimiter struct {
+	rate  float64
+	burst float64
+	users map[int64]*userBucket
+
+	mu sync.Mutex
+}
+
+// allow - пропустить ли обновление пользователя, warn - первое отклоненное подряд
+func (l *userLimiter) allow(userID int64, now time.Time) (allowed bool, warn bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.users) > idleUserLimit {
+		l.purge(now)
+	}
+
+	bucket, ok := l.users[userID]
+	if !ok {
+		bucket = &userBucket{tokens: l.burst, last: now}
+		l.users[userID] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.warned = false
+		return true, false
+	}
+
+	warn = !bucket.warned
+	bucket.warned = true
+	return false, warn
+}
+
+// purge - удаляет пользователей, у которых лимит уже восстановился полностью
+func (l *userLimiter) purge(now time.Time) {
+	for userID, bucket := range l.users {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.users, userID)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"runtime/debug"
+)
+
+// Recovery - паника обработчика пишется в лог и возвращается как ErrServerError
+func Recovery(log *logger.Logger) tgbot.Middleware {
+	return func(next tgbot.ViewFunc) tgbot.ViewFunc {
+		return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Error("panic recovered in %s: %v, %s", tgbot.RouteName(update), p, string(debug.Stack()))
+					err = customErr.ErrServerError
+				}
+			}()
+
+			return next(ctx, bot, update)
+		}
+	}
+}
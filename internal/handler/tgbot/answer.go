@@ -0,0 +1,53 @@
+package tgbot
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/internal/handler"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// answerTextLimit - ограничение Telegram на длину текста ответа на нажатие кнопки
+const answerTextLimit = 200
+
+// callbackAnswer - ответ на нажатие кнопки, отправляется после обработчика. Без текста
+// клиент только перестает показывать ожидание
+type callbackAnswer struct {
+	text  string
+	alert bool
+}
+
+type answerKey struct{}
+
+// Toast - короткое уведомление, которое клиент покажет поверх чата после нажатия кнопки
+func Toast(ctx context.Context, text string) {
+	setAnswer(ctx, text, false)
+}
+
+// Alert - окно с текстом, которое пользователь закрывает сам
+func Alert(ctx context.Context, text string) {
+	setAnswer(ctx, text, true)
+}
+
+func setAnswer(ctx context.Context, text string, alert bool) {
+	if answer, ok := ctx.Value(answerKey{}).(*callbackAnswer); ok {
+		answer.text, answer.alert = text, alert
+	}
+}
+
+// answerCallback - на каждое нажатие отвечается ровно один раз, ошибка обработчика показывается окном
+func (b *Bot) answerCallback(query *tgbotapi.CallbackQuery, answer *callbackAnswer, err error) {
+	if err != nil {
+		answer.text, answer.alert = handler.ErrorText(err), true
+	}
+
+	text := []rune(answer.text)
+	if len(text) > answerTextLimit {
+		text = append(text[:answerTextLimit-1], '…')
+	}
+
+	config := tgbotapi.NewCallback(query.ID, string(text))
+	config.ShowAlert = answer.alert
+	if _, err := b.bot.Request(config); err != nil {
+		b.log.Error("failed to answer callback query %s: %v", query.ID, err)
+	}
+}
@@ -4,29 +4,62 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/Enthreeka/tg-question-bot/internal/handler"
+	"github.com/Enthreeka/tg-question-bot/internal/handler/dialog"
 	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
-	store "github.com/Enthreeka/tg-question-bot/pkg/local_storage"
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/worker_pool"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"runtime/debug"
 	"sync"
 	"time"
 )
 
+const (
+	// updateTimeout - сколько может обрабатываться одно обновление
+	updateTimeout = 5 * time.Minute
+	// queueLogInterval - как часто в лог пишется глубина очередей обработчиков
+	queueLogInterval = time.Minute
+)
+
 type ViewFunc func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error
 
 type Bot struct {
-	bot           *tgbotapi.BotAPI
-	log           *logger.Logger
-	store         store.LocalStorage
-	tgMsg         *customMsg.TelegramMsg
-	userService   service.UserService
-	callbackStore *store.CallbackStorage
-
-	cmdView      map[string]ViewFunc
+	bot               *tgbotapi.BotAPI
+	log               *logger.Logger
+	tgMsg             customMsg.Message
+	dialogs           *dialog.Engine
+	userService       service.UserService
+	permissionService service.PermissionService
+	questionService   service.QuestionService
+	faqService        service.FaqService
+	relayService      service.RelayService
+
+	cmdView map[string]ViewFunc
+	// commands - зарегистрированные команды в порядке регистрации, из них строятся /help и меню
+	commands []Command
+	// commandChats - см. ScopeCommands, menuUsers - администраторы, которым опубликовано личное меню
+	commandChats []int64
+	menuMu       sync.Mutex
+	menuUsers    map[int64]struct{}
 	callbackView map[string]ViewFunc
+	replyView    ViewFunc
+	messageView  ViewFunc
+
+	// middlewares - middleware из Use, handler - dispatch с ними
+	middlewares []Middleware
+	handler     ViewFunc
+
+	adminChatID int64
+
+	// workers - сколько чатов обрабатывается параллельно, queueSize - очередь обновлений каждого обработчика
+	workers   int
+	queueSize int
+	// shutdownTimeout - сколько при остановке ждать завершения начатых и поставленных в очередь обновлений
+	shutdownTimeout time.Duration
+	// webhook - nil означает long polling
+	webhook *WebhookConfig
 
 	mu      sync.RWMutex
 	isDebug bool
@@ -34,153 +67,371 @@ type Bot struct {
 
 func NewBot(bot *tgbotapi.BotAPI,
 	log *logger.Logger,
-	store store.LocalStorage,
-	tgMsg *customMsg.TelegramMsg,
+	tgMsg customMsg.Message,
+	dialogs *dialog.Engine,
 	userService service.UserService,
-	callbackStore *store.CallbackStorage,
+	permissionService service.PermissionService,
+	questionService service.QuestionService,
+	faqService service.FaqService,
+	relayService service.RelayService,
+	adminChatID int64,
+	workers int,
+	queueSize int,
+	shutdownTimeout time.Duration,
 ) (*Bot, error) {
 	if log == nil {
 		return nil, errors.New("log is nil")
 	}
-	if store == nil {
-		return nil, errors.New("store is nil")
-	}
 	if tgMsg == nil {
 		return nil, errors.New("tgMsg is nil")
 	}
+	if dialogs == nil {
+		return nil, errors.New("dialogs is nil")
+	}
 	if userService == nil {
 		return nil, errors.New("userService is nil")
 	}
-	if callbackStore == nil {
-		return nil, errors.New("callbackStore is nil")
+	if permissionService == nil {
+		return nil, errors.New("permissionService is nil")
+	}
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if faqService == nil {
+		return nil, errors.New("faqService is nil")
+	}
+	if relayService == nil {
+		return nil, errors.New("relayService is nil")
 	}
 
 	return &Bot{
-		bot:           bot,
-		log:           log,
-		store:         store,
-		tgMsg:         tgMsg,
-		userService:   userService,
-		callbackStore: callbackStore,
+		bot:               bot,
+		log:               log,
+		tgMsg:             tgMsg,
+		dialogs:           dialogs,
+		userService:       userService,
+		permissionService: permissionService,
+		questionService:   questionService,
+		faqService:        faqService,
+		relayService:      relayService,
+		adminChatID:       adminChatID,
+		workers:           workers,
+		queueSize:         queueSize,
+		shutdownTimeout:   shutdownTimeout,
+		menuUsers:         make(map[int64]struct{}),
 	}, nil
 }
 
-func (b *Bot) RegisterCommandView(cmd string, view ViewFunc) {
+// RegisterCommandView - обработчик команды, middlewares вызываются только для нее.
+// Команда попадает в /help и меню команд
+func (b *Bot) RegisterCommandView(cmd Command, view ViewFunc, middlewares ...Middleware) {
 	if b.cmdView == nil {
 		b.cmdView = make(map[string]ViewFunc)
 	}
 
-	b.cmdView[cmd] = view
+	if _, ok := b.cmdView[cmd.Name]; !ok {
+		b.commands = append(b.commands, cmd)
+	}
+	b.cmdView[cmd.Name] = Chain(view, middlewares...)
 }
 
-func (b *Bot) RegisterCommandCallback(callback string, view ViewFunc) {
+// RegisterCommandCallback - обработчик кнопок действия callback, данные кнопок собираются action.Encode
+func (b *Bot) RegisterCommandCallback(callback string, view ViewFunc, middlewares ...Middleware) {
 	if b.callbackView == nil {
 		b.callbackView = make(map[string]ViewFunc)
 	}
 
-	b.callbackStore.AppendStorage(callback)
-	b.callbackView[callback] = view
+	b.callbackView[callback] = Chain(view, middlewares...)
 }
 
+// UseWebhook - получать обновления через вебхук вместо long polling
+func (b *Bot) UseWebhook(cfg WebhookConfig) {
+	b.webhook = &cfg
+}
+
+// RegisterReplyView - обработчик ответов администраторов на уведомления в чате администраторов
+func (b *Bot) RegisterReplyView(view ViewFunc, middlewares ...Middleware) {
+	b.replyView = Chain(view, middlewares...)
+}
+
+// RegisterMessageView - обработчик сообщений в личном чате, которые не являются командой или вводом в диалоге
+func (b *Bot) RegisterMessageView(view ViewFunc, middlewares ...Middleware) {
+	b.messageView = Chain(view, middlewares...)
+}
+
+// QuestionView - сообщение пользователя становится вопросом, см. askQuestion
+func (b *Bot) QuestionView() ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		b.askQuestion(ctx, update.Message)
+		return nil
+	}
+}
+
+// Run - принимает обновления до отмены ctx, затем дожидается обработки принятых обновлений
 func (b *Bot) Run(ctx context.Context) error {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	// handlerCtx не зависит от ctx: после сигнала остановки начатые обработчики должны завершиться,
+	// он отменяется, только если они не уложились в shutdownTimeout
+	handlerCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	b.handler = Chain(b.dispatch, b.middlewares...)
+
+	pool := worker_pool.New(b.workers, b.queueSize, b.workerPanic)
+
+	go b.logQueueDepth(ctx, pool)
+	go b.refreshCommands(ctx)
+
+	updates, failed, stop, err := b.receive()
+	if err != nil {
+		pool.Close()
+		return err
+	}
 
-	updates := b.bot.GetUpdatesChan(u)
 	for {
 		select {
 		case update := <-updates:
-			updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-
-			b.isDebug = false
 			b.jsonDebug(update)
-
-			b.handlerUpdate(updateCtx, &update)
-
-			cancel()
+			if err := b.submit(ctx, handlerCtx, pool, update); err != nil {
+				if ctx.Err() != nil {
+					// обновление уже принято, его обработка переходит в shutdown
+					return b.shutdown(handlerCtx, pool, updates, stop, cancelHandlers, update)
+				}
+				return err
+			}
+		case err := <-failed:
+			return errors.Join(err, b.shutdown(handlerCtx, pool, updates, stop, cancelHandlers))
 		case <-ctx.Done():
-			return ctx.Err()
+			return b.shutdown(handlerCtx, pool, updates, stop, cancelHandlers)
 		}
 	}
 }
 
-func (b *Bot) jsonDebug(update any) {
-	if b.isDebug {
-		updateByte, err := json.MarshalIndent(update, "", " ")
-		if err != nil {
-			b.log.Error("%v", err)
-		}
-		b.log.Info("%s", updateByte)
+// workerPanic - паника вне цепочки middleware, например при ответе на нажатие кнопки,
+// не останавливает бота: обработчик переходит к следующему обновлению
+func (b *Bot) workerPanic(recovered any, stack []byte) {
+	b.log.Error("panic recovered in update worker: %v, %s", recovered, stack)
+}
+
+// submit - ставит обновление в очередь обработчика его чата, ждет места в очереди до отмены ctx
+func (b *Bot) submit(ctx, handlerCtx context.Context, pool *worker_pool.Pool, update tgbotapi.Update) error {
+	key := updateKey(&update)
+	if pool.IsFull(key) {
+		b.log.Error("update queue of chat %d is full, receiving is paused", key)
 	}
+
+	return pool.Submit(ctx, key, func() {
+		updateCtx, cancel := context.WithTimeout(handlerCtx, updateTimeout)
+		defer cancel()
+
+		b.handlerUpdate(updateCtx, &update)
+	})
 }
 
-func (b *Bot) handlerUpdate(ctx context.Context, update *tgbotapi.Update) {
-	defer func() {
-		if p := recover(); p != nil {
-			b.log.Error("panic recovered: %v, %s", p, string(debug.Stack()))
-		}
-	}()
+// shutdown - прекращает прием обновлений и ждет обработчики не дольше shutdownTimeout.
+// Обновления, уже принятые, но еще не поставленные в очередь, тоже обрабатываются:
+// Telegram считает их доставленными и повторно не пришлет. pending - принятые обновления, которые Run
+// не успел поставить в очередь, они идут первыми
+func (b *Bot) shutdown(handlerCtx context.Context, pool *worker_pool.Pool, updates <-chan tgbotapi.Update, stop func(), cancelHandlers context.CancelFunc, pending ...tgbotapi.Update) error {
+	stop()
 
-	// if write message
-	if update.Message != nil {
-		b.log.Info("[%s] %s", update.Message.From.UserName, update.Message.Text)
+	drainCtx, cancel := context.WithTimeout(context.Background(), b.shutdownTimeout)
+	defer cancel()
 
-		isProcessing, err := b.isStoreProcessing(ctx, update)
-		if err != nil {
-			b.log.Error("failed in isStoreProcessing: %v", err)
-			handler.HandleError(b.bot, update, err)
-			return
+	for _, update := range pending {
+		if err := b.submit(drainCtx, handlerCtx, pool, update); err != nil {
+			b.log.Error("failed to queue update %d on shutdown: %v", update.UpdateID, err)
 		}
+	}
+	b.drain(drainCtx, handlerCtx, pool, updates)
 
-		if isProcessing {
-			return
-		}
+	var queued int
+	for _, depth := range pool.Depth() {
+		queued += depth
+	}
+	b.log.Info("Stopped receiving updates, waiting for handlers and %d queued updates", queued)
 
-		if err := b.userService.CreateUserIFNotExist(ctx, userUpdateToModel(update)); err != nil {
-			b.log.Error("userService.CreateUserIfNotExist: failed to create user: %v", err)
-			return
-		}
+	if err := pool.Shutdown(drainCtx); err != nil {
+		cancelHandlers()
+		return fmt.Errorf("handlers did not finish in %s: %w", b.shutdownTimeout, err)
+	}
 
-		// создание вопроса
-		if update.Message.Text != "/admin" && update.Message.Text != "/start" && update.Message.Text != "/cancel" {
-			go b.userService.CreateQuestion(context.Background(), update.FromChat().ID, update.Message.Text)
+	b.log.Info("All handlers finished")
+	return nil
+}
+
+// drain - передает в пул обновления, оставшиеся в канале после остановки приема
+func (b *Bot) drain(ctx, handlerCtx context.Context, pool *worker_pool.Pool, updates <-chan tgbotapi.Update) {
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			b.jsonDebug(update)
+			if err := b.submit(ctx, handlerCtx, pool, update); err != nil {
+				b.log.Error("failed to queue update %d on shutdown: %v", update.UpdateID, err)
+				return
+			}
+		default:
 			return
 		}
+	}
+}
 
-		var view ViewFunc
+// updateKey - обновления одного чата обрабатываются последовательно, разных чатов - параллельно
+func updateKey(update *tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if from := update.SentFrom(); from != nil {
+		return from.ID
+	}
+	return 0
+}
 
-		cmd := update.Message.Command()
+func (b *Bot) logQueueDepth(ctx context.Context, pool *worker_pool.Pool) {
+	ticker := time.NewTicker(queueLogInterval)
+	defer ticker.Stop()
 
-		cmdView, ok := b.cmdView[cmd]
-		if !ok {
+	for {
+		select {
+		case <-ticker.C:
+			var total, busiest int
+			for _, depth := range pool.Depth() {
+				total += depth
+				busiest = max(busiest, depth)
+			}
+			if total > 0 {
+				b.log.Info("update queue depth: %d, busiest worker: %d of %d", total, busiest, b.queueSize)
+			}
+		case <-ctx.Done():
 			return
 		}
+	}
+}
 
-		view = cmdView
+// askQuestion - при открытом диалоге сообщение уходит в тему группы поддержки, ответ на сообщение бота
+// о вопросе становится уточнением этого вопроса. Новый вопрос уходит аналитикам, только если в FAQ
+// не нашлось подходящего ответа
+func (b *Bot) askQuestion(ctx context.Context, message *tgbotapi.Message) {
+	var (
+		userID = message.From.ID
+		text   = message.Text
+	)
+
+	isRelayed, err := b.relayService.FromUser(ctx, message)
+	if err != nil {
+		b.log.Error("relayService.FromUser: failed to relay message of user %d: %v", userID, err)
+	}
+	if isRelayed {
+		return
+	}
 
-		if err := view(ctx, b.bot, update); err != nil {
-			b.log.Error("failed to handle VIEW update: %v", err)
-			handler.HandleError(b.bot, update, err)
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil && reply.From.ID == b.bot.Self.ID {
+		isFollowUp, err := b.questionService.FollowUp(ctx, userID, reply.MessageID, text)
+		if err != nil {
+			b.log.Error("questionService.FollowUp: failed to attach follow-up of user %d: %v", userID, err)
+		}
+		if isFollowUp {
 			return
 		}
-		//  if press button
-	} else if update.CallbackQuery != nil {
-		b.log.Info("[%s] %s", update.CallbackQuery.From.UserName, update.CallbackData())
+	}
 
-		var callback ViewFunc
+	suggested, err := b.faqService.Suggest(ctx, userID, text)
+	if err != nil {
+		b.log.Error("faqService.Suggest: failed to suggest answer to user %d: %v", userID, err)
+	}
+	if suggested {
+		return
+	}
 
-		err, callbackView := b.CallbackStrings(update.CallbackData())
+	if err := b.questionService.CreateQuestion(ctx, userID, text); err != nil {
+		b.log.Error("questionService.CreateQuestion: failed to create question of user %d: %v", userID, err)
+	}
+}
+
+func (b *Bot) jsonDebug(update any) {
+	if b.isDebug {
+		updateByte, err := json.MarshalIndent(update, "", " ")
 		if err != nil {
 			b.log.Error("%v", err)
-			return
 		}
+		b.log.Info("%s", updateByte)
+	}
+}
 
-		callback = callbackView
+// handlerUpdate - обновление проходит через middleware из Use. Ошибка обработчика кнопки показывается
+// в ответе на нажатие, ошибка обработчика сообщения - новым сообщением
+func (b *Bot) handlerUpdate(ctx context.Context, update *tgbotapi.Update) {
+	var answer *callbackAnswer
+	if update.CallbackQuery != nil {
+		answer = new(callbackAnswer)
+		ctx = context.WithValue(ctx, answerKey{}, answer)
+	}
 
-		if err := callback(ctx, b.bot, update); err != nil {
-			b.log.Error("failed to handle CALLBACK update: %v", err)
-			handler.HandleError(b.bot, update, err)
-			return
+	err := b.handler(ctx, b.bot, update)
+
+	switch {
+	case answer != nil:
+		b.answerCallback(update.CallbackQuery, answer, err)
+	case err != nil && update.FromChat() != nil:
+		// ошибка могла быть вызвана истекшим ctx, сообщить о ней нужно все равно
+		handler.HandleError(context.WithoutCancel(ctx), b.tgMsg, update, err)
+	}
+}
+
+func (b *Bot) dispatch(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+	switch {
+	case update.Message != nil:
+		return b.dispatchMessage(ctx, update)
+	case update.CallbackQuery != nil:
+		return b.dispatchCallback(ctx, update)
+	}
+	return nil
+}
+
+func (b *Bot) dispatchMessage(ctx context.Context, update *tgbotapi.Update) error {
+	isProcessing, err := b.dialogs.Handle(ctx, update)
+	if err != nil || isProcessing {
+		return err
+	}
+
+	if err := b.userService.CreateUserIFNotExist(ctx, userUpdateToModel(update)); err != nil {
+		b.log.Error("userService.CreateUserIfNotExist: failed to create user: %v", err)
+		return nil
+	}
+
+	isCommand := update.Message.IsCommand()
+
+	// сообщение администратора в теме переписки группы поддержки
+	if !update.Message.Chat.IsPrivate() && !isCommand {
+		isRelayed, err := b.relayService.FromAdmin(ctx, update.Message)
+		if err != nil || isRelayed {
+			return err
 		}
 	}
+
+	// ответ на уведомление в чате администраторов
+	if update.Message.Chat.ID == b.adminChatID && update.Message.ReplyToMessage != nil && b.replyView != nil {
+		return b.replyView(ctx, b.bot, update)
+	}
+
+	// создание вопроса, вопросы принимаются только в личных сообщениях
+	isQuestionChat := update.Message.Chat.IsPrivate() && update.Message.Chat.ID != b.adminChatID
+	if isQuestionChat && !isCommand {
+		if b.messageView == nil {
+			return nil
+		}
+		return b.messageView(ctx, b.bot, update)
+	}
+
+	if !isCommand {
+		return nil
+	}
+
+	cmdView, ok := b.cmdView[update.Message.Command()]
+	if !ok {
+		return b.unknownCommand(ctx, update.Message)
+	}
+	return cmdView(ctx, b.bot, update)
 }
@@ -0,0 +1,71 @@
+package tgbot
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/worker_pool"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"testing"
+	"time"
+)
+
+func TestPanickingViewDoesNotStopWorker(t *testing.T) {
+	handled := make(chan int, 1)
+	b := &Bot{log: logger.New()}
+	b.handler = func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		if update.Message.Text == "panic" {
+			panic("view failed")
+		}
+		handled <- update.UpdateID
+		return nil
+	}
+
+	pool := worker_pool.New(1, 1, b.workerPanic)
+	defer pool.Close()
+
+	ctx := context.Background()
+	chat := &tgbotapi.Chat{ID: 100, Type: "private"}
+	for i, text := range []string{"panic", "hello"} {
+		update := tgbotapi.Update{UpdateID: i, Message: &tgbotapi.Message{Text: text, Chat: chat}}
+		if err := b.submit(ctx, ctx, pool, update); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case id := <-handled:
+		if id != 1 {
+			t.Fatalf("handled update %d, want 1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update after panic was not handled")
+	}
+}
+
+func TestShutdownHandlesPendingUpdate(t *testing.T) {
+	handled := make(chan int, 2)
+	b := &Bot{log: logger.New(), shutdownTimeout: time.Second}
+	b.handler = func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		handled <- update.UpdateID
+		return nil
+	}
+
+	chat := &tgbotapi.Chat{ID: 100, Type: "private"}
+	updates := make(chan tgbotapi.Update, 1)
+	updates <- tgbotapi.Update{UpdateID: 2, Message: &tgbotapi.Message{Chat: chat}}
+
+	pool := worker_pool.New(1, 0, b.workerPanic)
+	pending := tgbotapi.Update{UpdateID: 1, Message: &tgbotapi.Message{Chat: chat}}
+	if err := b.shutdown(context.Background(), pool, updates, func() {}, func() {}, pending); err != nil {
+		t.Fatal(err)
+	}
+	close(handled)
+
+	var got []int
+	for id := range handled {
+		got = append(got, id)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("handled updates = %v, want [1 2]", got)
+	}
+}
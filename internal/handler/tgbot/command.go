@@ -0,0 +1,202 @@
+package tgbot
+
+import (
+	"context"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strings"
+	"time"
+)
+
+// commandsRefreshInterval - как часто меню команд администраторов приводится в соответствие с их ролями
+const commandsRefreshInterval = time.Hour
+
+// Command - зарегистрированная команда, описание показывается в /help и в меню команд Telegram.
+// Permission - право, без которого команда не показывается, пустое - команда доступна всем
+type Command struct {
+	Name        string
+	Description string
+	Permission  entity.Permission
+}
+
+func (c Command) isPublic() bool {
+	return c.Permission == ""
+}
+
+// ScopeCommands - чаты, участникам которых меню показывает все команды, например чат администраторов
+func (b *Bot) ScopeCommands(chatIDs ...int64) {
+	for _, chatID := range chatIDs {
+		if chatID != 0 {
+			b.commandChats = append(b.commandChats, chatID)
+		}
+	}
+}
+
+// HelpView - /help, список команд, доступных пользователю
+func (b *Bot) HelpView() ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		var public, admin []Command
+		for _, command := range b.allowedCommands(ctx, update.SentFrom().ID) {
+			if command.isPublic() {
+				public = append(public, command)
+			} else {
+				admin = append(admin, command)
+			}
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Команды бота:\n")
+		writeCommands(&sb, public)
+		if len(admin) > 0 {
+			sb.WriteString("\nДля администраторов:\n")
+			writeCommands(&sb, admin)
+		}
+
+		_, err := b.tgMsg.SendNewMessage(ctx, update.FromChat().ID, nil, sb.String())
+		return err
+	}
+}
+
+func writeCommands(sb *strings.Builder, commands []Command) {
+	for _, command := range commands {
+		fmt.Fprintf(sb, "/%s — %s\n", command.Name, html.EscapeString(command.Description))
+	}
+}
+
+// unknownCommand - ответ на незарегистрированную команду. В группе отвечает, только если команда
+// адресована этому боту, чтобы не мешать другим ботам чата
+func (b *Bot) unknownCommand(ctx context.Context, message *tgbotapi.Message) error {
+	if !message.Chat.IsPrivate() && !strings.HasSuffix(message.CommandWithAt(), "@"+b.bot.Self.UserName) {
+		return nil
+	}
+
+	text := fmt.Sprintf("Неизвестная команда /%s. Список команд — /help", html.EscapeString(message.Command()))
+	_, err := b.tgMsg.SendNewMessage(ctx, message.Chat.ID, nil, text)
+	return err
+}
+
+// allowedCommands - публичные команды и команды, на которые у пользователя есть права.
+// Если права получить не удалось, только публичные
+func (b *Bot) allowedCommands(ctx context.Context, userID int64) []Command {
+	permissions, err := b.permissionService.GetUserPermissions(ctx, userID)
+	if err != nil {
+		b.log.Error("permissionService.GetUserPermissions: failed to get permissions of %d: %v", userID, err)
+	}
+	return b.commandsFor(permissions)
+}
+
+func (b *Bot) commandsFor(permissions entity.PermissionSet) []Command {
+	commands := make([]Command, 0, len(b.commands))
+	for _, command := range b.commands {
+		if command.isPublic() || permissions.Has(command.Permission) {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// refreshCommands - публикует меню команд при запуске и обновляет его до отмены ctx
+func (b *Bot) refreshCommands(ctx context.Context) {
+	ticker := time.NewTicker(commandsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		b.publishCommands(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishCommands - публичные команды видны всем, в личном чате администратора - еще и команды по его правам,
+// в чатах ScopeCommands - все команды. У пользователей, потерявших доступ к панели, меню сбрасывается
+func (b *Bot) publishCommands(ctx context.Context) {
+	b.menuMu.Lock()
+	defer b.menuMu.Unlock()
+
+	var public []Command
+	for _, command := range b.commands {
+		if command.isPublic() {
+			public = append(public, command)
+		}
+	}
+	b.setCommands(ctx, tgbotapi.NewBotCommandScopeDefault(), public)
+
+	for _, chatID := range b.commandChats {
+		b.setCommands(ctx, tgbotapi.NewBotCommandScopeChat(chatID), b.commands)
+	}
+
+	admins, err := b.userService.GetUsersWithPermission(ctx, entity.PermPanelAccess)
+	if err != nil {
+		b.log.Error("userService.GetUsersWithPermission: failed to get admins for command menu: %v", err)
+		return
+	}
+
+	current := make(map[int64]struct{}, len(admins))
+	for _, admin := range admins {
+		current[admin.ID] = struct{}{}
+		b.setCommands(ctx, tgbotapi.NewBotCommandScopeChat(admin.ID), b.allowedCommands(ctx, admin.ID))
+	}
+
+	for userID := range b.menuUsers {
+		if _, ok := current[userID]; ok {
+			continue
+		}
+		if !b.deleteCommands(ctx, userID) {
+			// повторить при следующем обновлении
+			current[userID] = struct{}{}
+		}
+	}
+	b.menuUsers = current
+}
+
+// PublishUserCommands - обновляет личное меню пользователя сразу после смены его роли,
+// не дожидаясь очередного refreshCommands
+func (b *Bot) PublishUserCommands(ctx context.Context, userID int64) {
+	b.menuMu.Lock()
+	defer b.menuMu.Unlock()
+
+	permissions, err := b.permissionService.GetUserPermissions(ctx, userID)
+	if err != nil {
+		b.log.Error("permissionService.GetUserPermissions: failed to get permissions of %d: %v", userID, err)
+		return
+	}
+
+	if permissions.Has(entity.PermPanelAccess) {
+		b.setCommands(ctx, tgbotapi.NewBotCommandScopeChat(userID), b.commandsFor(permissions))
+		b.menuUsers[userID] = struct{}{}
+		return
+	}
+
+	if b.deleteCommands(ctx, userID) {
+		delete(b.menuUsers, userID)
+	}
+}
+
+// deleteCommands - убирает личное меню, пользователь снова видит публичные команды
+func (b *Bot) deleteCommands(ctx context.Context, userID int64) bool {
+	if err := b.tgMsg.DeleteCommands(ctx, tgbotapi.NewBotCommandScopeChat(userID)); err != nil {
+		b.log.Error("failed to delete command menu of user %d: %v", userID, err)
+		return false
+	}
+	return true
+}
+
+func (b *Bot) setCommands(ctx context.Context, scope tgbotapi.BotCommandScope, commands []Command) {
+	botCommands := make([]tgbotapi.BotCommand, 0, len(commands))
+	for _, command := range commands {
+		botCommands = append(botCommands, tgbotapi.BotCommand{
+			Command:     command.Name,
+			Description: command.Description,
+		})
+	}
+
+	if err := b.tgMsg.SetCommands(ctx, scope, botCommands); err != nil {
+		b.log.Error("failed to set commands for scope %s %d: %v", scope.Type, scope.ChatID, err)
+	}
+}
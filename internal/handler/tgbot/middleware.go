@@ -0,0 +1,73 @@
+package tgbot
+
+import (
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Middleware - обертка обработчика: проверка прав, логирование, ограничения
+type Middleware func(next ViewFunc) ViewFunc
+
+// Chain - view с middlewares, первая из них вызывается первой
+func Chain(view ViewFunc, middlewares ...Middleware) ViewFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		view = middlewares[i](view)
+	}
+	return view
+}
+
+// Use - middleware для всех обновлений: команд, кнопок, сообщений и ввода в диалогах
+func (b *Bot) Use(middlewares ...Middleware) {
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
+// Group - обработчики с общими middleware, например с одним правом доступа
+type Group struct {
+	bot         *Bot
+	middlewares []Middleware
+}
+
+func (b *Bot) Group(middlewares ...Middleware) *Group {
+	return &Group{bot: b, middlewares: middlewares}
+}
+
+// Group - вложенная группа, middleware родителя вызываются раньше
+func (g *Group) Group(middlewares ...Middleware) *Group {
+	return &Group{bot: g.bot, middlewares: g.with(middlewares)}
+}
+
+func (g *Group) RegisterCommandView(cmd Command, view ViewFunc, middlewares ...Middleware) {
+	g.bot.RegisterCommandView(cmd, view, g.with(middlewares)...)
+}
+
+func (g *Group) RegisterCommandCallback(callback string, view ViewFunc, middlewares ...Middleware) {
+	g.bot.RegisterCommandCallback(callback, view, g.with(middlewares)...)
+}
+
+func (g *Group) RegisterReplyView(view ViewFunc, middlewares ...Middleware) {
+	g.bot.RegisterReplyView(view, g.with(middlewares)...)
+}
+
+func (g *Group) RegisterMessageView(view ViewFunc, middlewares ...Middleware) {
+	g.bot.RegisterMessageView(view, g.with(middlewares)...)
+}
+
+func (g *Group) with(middlewares []Middleware) []Middleware {
+	return append(append([]Middleware{}, g.middlewares...), middlewares...)
+}
+
+// RouteName - имя обработчика обновления для логов и метрик
+func RouteName(update *tgbotapi.Update) string {
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return "/" + update.Message.Command()
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		if data, err := action.Parse(update.CallbackData()); err == nil {
+			return data.Action
+		}
+		return "callback"
+	}
+	return "other"
+}
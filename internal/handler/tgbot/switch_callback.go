@@ -1,19 +1,51 @@
 package tgbot
 
 import (
+	"context"
+	"errors"
 	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"strings"
 )
 
-func (b *Bot) CallbackStrings(callbackData string) (error, ViewFunc) {
-	for key, _ := range b.callbackStore.GetStorage() {
-		if strings.HasPrefix(callbackData, key) || strings.HasPrefix(callbackData, key+"_") {
-			callbackView, ok := b.callbackView[key]
-			if !ok {
-				return customErr.ErrNotFound, nil
+func (b *Bot) dispatchCallback(ctx context.Context, update *tgbotapi.Update) error {
+	callback, data, err := b.route(update.CallbackData())
+	if err != nil {
+		b.log.Error("failed to route callback %q: %v", update.CallbackData(), err)
+		if errors.Is(err, customErr.ErrButtonExpired) {
+			return err
+		}
+		return nil
+	}
+
+	return callback(action.WithData(ctx, data), b.bot, update)
+}
+
+// route - обработчик кнопки по точному имени действия. Кнопки старого формата action_arg1_arg2
+// в отправленных ранее сообщениях сопоставляются с самым длинным подходящим действием
+func (b *Bot) route(callbackData string) (ViewFunc, action.Data, error) {
+	data, err := action.Parse(callbackData)
+	if err != nil {
+		return nil, data, err
+	}
+
+	if view, ok := b.callbackView[data.Action]; ok {
+		return view, data, nil
+	}
+
+	if len(data.Args) == 0 {
+		var legacy string
+		for key := range b.callbackView {
+			if strings.HasPrefix(data.Action, key+"_") && len(key) > len(legacy) {
+				legacy = key
 			}
-			return nil, callbackView
+		}
+		if legacy != "" {
+			args := strings.Split(strings.TrimPrefix(data.Action, legacy+"_"), "_")
+			return b.callbackView[legacy], action.Data{Action: legacy, Args: args}, nil
 		}
 	}
-	return nil, nil
+
+	return nil, data, customErr.ErrNotFound
 }
@@ -0,0 +1,153 @@
+package tgbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"time"
+)
+
+// secretTokenHeader - Telegram передает в нем secret_token, указанный в setWebhook
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhookStopTimeout - сколько ждать завершения запросов при остановке HTTP-сервера
+const webhookStopTimeout = 10 * time.Second
+
+type WebhookConfig struct {
+	// Listen - адрес HTTP-сервера, например ":8443"
+	Listen string
+	// Path - путь, на который Telegram отправляет обновления
+	Path string
+	// URL - публичный адрес вебхука для setWebhook. Пустой URL не регистрирует вебхук,
+	// обновления можно отправлять на Listen вручную
+	URL    string
+	Secret string
+	// CertFile и KeyFile включают TLS
+	CertFile string
+	KeyFile  string
+}
+
+// WebhookHandler - принимает JSON обновлений и передает их в updates
+type WebhookHandler struct {
+	secret  string
+	updates chan<- tgbotapi.Update
+	done    <-chan struct{}
+	log     *logger.Logger
+}
+
+// NewWebhookHandler - принимаются только запросы с заголовком secret. После закрытия done
+// обработчик отвечает 503, Telegram повторит доставку позже
+func NewWebhookHandler(secret string, updates chan<- tgbotapi.Update, done <-chan struct{}, log *logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		secret:  secret,
+		updates: updates,
+		done:    done,
+		log:     log,
+	}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(h.secret)) != 1 {
+		h.log.Error("webhook request with invalid secret token from %s", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		h.log.Error("failed to decode webhook update: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case h.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-h.done:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+// receive - источник обновлений: long polling или вебхук. stop прекращает прием обновлений,
+// в failed приходит ошибка, после которой обновления больше не поступают
+func (b *Bot) receive() (updates <-chan tgbotapi.Update, failed <-chan error, stop func(), err error) {
+	if b.webhook == nil {
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+
+		return b.bot.GetUpdatesChan(u), nil, b.bot.StopReceivingUpdates, nil
+	}
+
+	return b.listenWebhook(*b.webhook)
+}
+
+func (b *Bot) listenWebhook(cfg WebhookConfig) (<-chan tgbotapi.Update, <-chan error, func(), error) {
+	var (
+		updates = make(chan tgbotapi.Update, b.bot.Buffer)
+		done    = make(chan struct{})
+		failed  = make(chan error, 1)
+		mux     = http.NewServeMux()
+	)
+	mux.Handle(cfg.Path, NewWebhookHandler(cfg.Secret, updates, done, b.log))
+
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		var err error
+		if cfg.CertFile != "" {
+			err = server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failed <- fmt.Errorf("webhook server failed: %w", err)
+		}
+	}()
+	b.log.Info("Webhook server is listening on %s%s", cfg.Listen, cfg.Path)
+
+	if cfg.URL != "" {
+		params := tgbotapi.Params{}
+		params.AddNonEmpty("url", cfg.URL)
+		params.AddNonEmpty("secret_token", cfg.Secret)
+
+		if _, err := b.bot.MakeRequest("setWebhook", params); err != nil {
+			_ = server.Close()
+			return nil, nil, nil, err
+		}
+		b.log.Info("Webhook registered at %s", cfg.URL)
+	}
+
+	stop := func() {
+		if cfg.URL != "" {
+			if _, err := b.bot.MakeRequest("deleteWebhook", tgbotapi.Params{}); err != nil {
+				b.log.Error("failed to delete webhook: %v", err)
+			}
+		}
+
+		close(done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), webhookStopTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			b.log.Error("failed to stop webhook server: %v", err)
+		}
+	}
+
+	return updates, failed, stop, nil
+}
@@ -0,0 +1,84 @@
+package tgbot
+
+import (
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSecret = "test-secret"
+
+func newTestWebhook(t *testing.T) (*httptest.Server, chan tgbotapi.Update) {
+	t.Helper()
+
+	updates := make(chan tgbotapi.Update, 1)
+	server := httptest.NewServer(NewWebhookHandler(testSecret, updates, make(chan struct{}), logger.New()))
+	t.Cleanup(server.Close)
+
+	return server, updates
+}
+
+func postUpdate(t *testing.T, url, secret, body string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestWebhookHandler(t *testing.T) {
+	const update = `{"update_id": 42, "message": {"message_id": 1, "text": "hello",
+		"from": {"id": 100}, "chat": {"id": 100, "type": "private"}}}`
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		status int
+	}{
+		{name: "wrong secret", secret: "wrong", body: update, status: http.StatusUnauthorized},
+		{name: "no secret", body: update, status: http.StatusUnauthorized},
+		{name: "malformed body", secret: testSecret, body: `{"update_id":`, status: http.StatusBadRequest},
+		{name: "valid update", secret: testSecret, body: update, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, updates := newTestWebhook(t)
+
+			if status := postUpdate(t, server.URL, tt.secret, tt.body); status != tt.status {
+				t.Fatalf("status = %d, want %d", status, tt.status)
+			}
+
+			select {
+			case got := <-updates:
+				if tt.status != http.StatusOK {
+					t.Fatalf("update %d delivered for rejected request", got.UpdateID)
+				}
+				if got.UpdateID != 42 || got.Message == nil || got.Message.Text != "hello" {
+					t.Fatalf("unexpected update: %+v", got)
+				}
+			default:
+				if tt.status == http.StatusOK {
+					t.Fatal("update was not delivered")
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package view
 import (
 	"context"
 	"github.com/Enthreeka/tg-question-bot/internal/handler/tgbot"
+	service "github.com/Enthreeka/tg-question-bot/internal/usecase"
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
 	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
@@ -11,27 +12,35 @@ import (
 )
 
 type ViewGeneral struct {
-	log   *logger.Logger
-	tgMsg customMsg.Message
-	pg    *postgres.Postgres
+	log               *logger.Logger
+	tgMsg             customMsg.Message
+	pg                *postgres.Postgres
+	permissionService service.PermissionService
 }
 
 func NewViewGeneral(
 	log *logger.Logger,
 	tgMsg customMsg.Message,
 	pg *postgres.Postgres,
+	permissionService service.PermissionService,
 ) *ViewGeneral {
 	return &ViewGeneral{
-		log:   log,
-		tgMsg: tgMsg,
-		pg:    pg,
+		log:               log,
+		tgMsg:             tgMsg,
+		pg:                pg,
+		permissionService: permissionService,
 	}
 }
 
 func (c *ViewGeneral) CallbackStartAdminPanel() tgbot.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update *tgbotapi.Update) error {
+		permissions, err := c.permissionService.GetUserPermissions(ctx, update.Message.From.ID)
+		if err != nil {
+			return err
+		}
 
-		if _, err := c.tgMsg.SendNewMessage(update.FromChat().ID, &markup.StartMenu, "Панель управления"); err != nil {
+		startMenu := markup.StartMenu.For(permissions.Allowed)
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, &startMenu, "Панель управления"); err != nil {
 			return err
 		}
 
@@ -46,7 +55,8 @@ func (c *ViewGeneral) CallbackStartUser() tgbot.ViewFunc {
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonURL("Перейти в канал", "https://t.me/MoscowEcon")),
 		)
-		if _, err := c.tgMsg.SendNewMessage(update.FromChat().ID, &startMenu, "Привет!\nЗадайте вопросы нашим аналитикам. На самые интересные из них мы ответим в Telegram-канале «Экономика Москвы»."); err != nil {
+		if _, err := c.tgMsg.SendNewMessage(ctx, update.FromChat().ID, &startMenu, "Привет!\nЗадайте вопросы нашим аналитикам. На самые интересные из них мы ответим в Telegram-канале «Экономика Москвы».\n\n"+
+			"Проголосовать за вопросы других читателей: /vote"); err != nil {
 			c.log.Error("Failed to send start menu: ", err)
 			return nil
 		}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditRepo interface {
+	Create(ctx context.Context, log *entity.AuditLog) error
+
+	GetPage(ctx context.Context, limit, offset int) ([]entity.AuditLog, error)
+	GetAll(ctx context.Context) ([]entity.AuditLog, error)
+	Count(ctx context.Context) (int, error)
+}
+
+type auditRepo struct {
+	*postgres.Postgres
+}
+
+func NewAuditRepo(pg *postgres.Postgres) (AuditRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &auditRepo{
+		pg,
+	}, nil
+}
+
+func (a *auditRepo) collectRows(rows pgx.Rows) ([]entity.AuditLog, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AuditLog, error) {
+		var (
+			log      entity.AuditLog
+			username *string
+		)
+		err := row.Scan(&log.ID, &log.ActorID, &username, &log.Action, &log.Target, &log.CreatedAt, &log.Diff)
+		if username != nil {
+			log.ActorUsername = *username
+		}
+		return log, ErrorHandler(err)
+	})
+}
+
+func (a *auditRepo) Create(ctx context.Context, log *entity.AuditLog) error {
+	query := `insert into audit_log (actor_id,action,target,created_at,diff) values ($1,$2,$3,$4,$5)`
+
+	_, err := a.Pool.Exec(ctx, query, log.ActorID, log.Action, log.Target, log.CreatedAt, log.Diff)
+	return err
+}
+
+func (a *auditRepo) GetPage(ctx context.Context, limit, offset int) ([]entity.AuditLog, error) {
+	query := `select a.id, a.actor_id, u.tg_username, a.action, a.target, a.created_at, a.diff
+			from audit_log a
+			left join "user" u on u.id = a.actor_id
+			order by a.created_at desc, a.id desc
+			limit $1 offset $2`
+
+	rows, err := a.Pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return a.collectRows(rows)
+}
+
+func (a *auditRepo) GetAll(ctx context.Context) ([]entity.AuditLog, error) {
+	query := `select a.id, a.actor_id, u.tg_username, a.action, a.target, a.created_at, a.diff
+			from audit_log a
+			left join "user" u on u.id = a.actor_id
+			order by a.created_at desc, a.id desc`
+
+	rows, err := a.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return a.collectRows(rows)
+}
+
+func (a *auditRepo) Count(ctx context.Context) (int, error) {
+	query := `select count(*) from audit_log`
+	var count int
+
+	err := a.Pool.QueryRow(ctx, query).Scan(&count)
+	return count, ErrorHandler(err)
+}
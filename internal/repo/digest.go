@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type DigestRepo interface {
+	GetLastSent(ctx context.Context, kind entity.DigestKind) (time.Time, error)
+	UpdateLastSent(ctx context.Context, kind entity.DigestKind, sentAt time.Time) error
+
+	GetSubscribers(ctx context.Context, kind entity.DigestKind) ([]int64, error)
+	GetSubscriptions(ctx context.Context, userID int64) ([]entity.DigestKind, error)
+	Subscribe(ctx context.Context, userID int64, kind entity.DigestKind) error
+	Unsubscribe(ctx context.Context, userID int64, kind entity.DigestKind) error
+}
+
+type digestRepo struct {
+	*postgres.Postgres
+}
+
+func NewDigestRepo(pg *postgres.Postgres) (DigestRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &digestRepo{
+		pg,
+	}, nil
+}
+
+// GetLastSent - нулевое время, если дайджест еще ни разу не отправлялся
+func (d *digestRepo) GetLastSent(ctx context.Context, kind entity.DigestKind) (time.Time, error) {
+	query := `select last_sent_at from digest_state where kind = $1`
+	var lastSent time.Time
+
+	err := d.Pool.QueryRow(ctx, query, kind).Scan(&lastSent)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return lastSent, err
+}
+
+func (d *digestRepo) UpdateLastSent(ctx context.Context, kind entity.DigestKind, sentAt time.Time) error {
+	query := `insert into digest_state (kind, last_sent_at) values ($1, $2)
+			on conflict (kind) do update set last_sent_at = excluded.last_sent_at`
+
+	_, err := d.DB(ctx).Exec(ctx, query, kind, sentAt)
+	return err
+}
+
+// GetSubscribers - подписчики, у которых осталась роль в панели управления
+func (d *digestRepo) GetSubscribers(ctx context.Context, kind entity.DigestKind) ([]int64, error) {
+	query := `select ds.user_id from digest_subscription ds
+			join "user" u on u.id = ds.user_id
+			where ds.kind = $1 and u.user_role <> 'user'`
+
+	rows, err := d.Pool.Query(ctx, query, kind)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+func (d *digestRepo) GetSubscriptions(ctx context.Context, userID int64) ([]entity.DigestKind, error) {
+	query := `select kind from digest_subscription where user_id = $1`
+
+	rows, err := d.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[entity.DigestKind])
+}
+
+func (d *digestRepo) Subscribe(ctx context.Context, userID int64, kind entity.DigestKind) error {
+	query := `insert into digest_subscription (user_id, kind) values ($1, $2) on conflict do nothing`
+
+	_, err := d.Pool.Exec(ctx, query, userID, kind)
+	return err
+}
+
+func (d *digestRepo) Unsubscribe(ctx context.Context, userID int64, kind entity.DigestKind) error {
+	query := `delete from digest_subscription where user_id = $1 and kind = $2`
+
+	_, err := d.Pool.Exec(ctx, query, userID, kind)
+	return err
+}
@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type FaqRepo interface {
+	Create(ctx context.Context, faq *entity.Faq) (int, error)
+	Delete(ctx context.Context, id int) error
+	GetAll(ctx context.Context) ([]entity.Faq, error)
+	GetByID(ctx context.Context, id int) (*entity.Faq, error)
+
+	CreateSuggestion(ctx context.Context, suggestion *entity.FaqSuggestion) (int, error)
+	GetSuggestion(ctx context.Context, id int) (*entity.FaqSuggestion, error)
+	// SetOutcome - false, если пользователь уже принял решение по подсказке
+	SetOutcome(ctx context.Context, id int, outcome entity.FaqOutcome) (bool, error)
+	GetStats(ctx context.Context) ([]entity.FaqStats, error)
+}
+
+type faqRepo struct {
+	*postgres.Postgres
+}
+
+func NewFaqRepo(pg *postgres.Postgres) (FaqRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &faqRepo{
+		pg,
+	}, nil
+}
+
+const faqColumns = `id, patterns, answer, coalesce(created_by, 0), created_at`
+
+func scanFaq(row pgx.Row) (entity.Faq, error) {
+	var faq entity.Faq
+	err := row.Scan(&faq.ID, &faq.Patterns, &faq.Answer, &faq.CreatedBy, &faq.CreatedAt)
+	return faq, ErrorHandler(err)
+}
+
+func (f *faqRepo) Create(ctx context.Context, faq *entity.Faq) (int, error) {
+	query := `insert into faq (patterns, answer, created_by) values ($1, $2, $3) returning id`
+	var id int
+
+	err := f.Pool.QueryRow(ctx, query, faq.Patterns, faq.Answer, faq.CreatedBy).Scan(&id)
+	return id, ErrorHandler(err)
+}
+
+func (f *faqRepo) Delete(ctx context.Context, id int) error {
+	query := `delete from faq where id = $1`
+
+	_, err := f.Pool.Exec(ctx, query, id)
+	return err
+}
+
+func (f *faqRepo) GetAll(ctx context.Context) ([]entity.Faq, error) {
+	query := `select ` + faqColumns + ` from faq order by id`
+
+	rows, err := f.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Faq, error) {
+		return scanFaq(row)
+	})
+}
+
+func (f *faqRepo) GetByID(ctx context.Context, id int) (*entity.Faq, error) {
+	query := `select ` + faqColumns + ` from faq where id = $1`
+
+	faq, err := scanFaq(f.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+	return &faq, nil
+}
+
+func (f *faqRepo) CreateSuggestion(ctx context.Context, suggestion *entity.FaqSuggestion) (int, error) {
+	query := `insert into faq_suggestion (faq_id, user_id, question) values ($1, $2, $3) returning id`
+	var id int
+
+	err := f.Pool.QueryRow(ctx, query, suggestion.FaqID, suggestion.UserID, suggestion.Question).Scan(&id)
+	return id, ErrorHandler(err)
+}
+
+func (f *faqRepo) GetSuggestion(ctx context.Context, id int) (*entity.FaqSuggestion, error) {
+	query := `select id, faq_id, user_id, question, coalesce(outcome, ''), created_at from faq_suggestion where id = $1`
+	var suggestion entity.FaqSuggestion
+
+	err := f.Pool.QueryRow(ctx, query, id).Scan(&suggestion.ID, &suggestion.FaqID, &suggestion.UserID,
+		&suggestion.Question, &suggestion.Outcome, &suggestion.CreatedAt)
+	if err != nil {
+		return nil, ErrorHandler(err)
+	}
+	return &suggestion, nil
+}
+
+func (f *faqRepo) SetOutcome(ctx context.Context, id int, outcome entity.FaqOutcome) (bool, error) {
+	query := `update faq_suggestion set outcome = $2, resolved_at = now() where id = $1 and outcome is null`
+
+	tag, err := f.Pool.Exec(ctx, query, id, outcome)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (f *faqRepo) GetStats(ctx context.Context) ([]entity.FaqStats, error) {
+	query := `select faq_id,
+				count(*),
+				count(*) filter (where outcome = 'helped'),
+				count(*) filter (where outcome = 'sent')
+			from faq_suggestion
+			group by faq_id`
+
+	rows, err := f.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.FaqStats, error) {
+		var stats entity.FaqStats
+		err := row.Scan(&stats.FaqID, &stats.Shown, &stats.Helped, &stats.Sent)
+		return stats, err
+	})
+}
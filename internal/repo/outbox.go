@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"slices"
+	"time"
+)
+
+type OutboxRepo interface {
+	// Create - пишет в транзакцию из контекста, если она открыта
+	Create(ctx context.Context, message *entity.OutboxMessage) error
+
+	// ClaimDue - забирает до limit сообщений, время отправки которых наступило, и откладывает их на lease,
+	// чтобы сообщение, не отмеченное после отправки, было отправлено повторно
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxMessage, error)
+	MarkSent(ctx context.Context, id int64) error
+	Reschedule(ctx context.Context, id int64, lastError string, delay time.Duration) error
+	MarkDead(ctx context.Context, id int64, lastError string) error
+
+	GetDead(ctx context.Context, limit int) ([]entity.OutboxMessage, error)
+	GetStats(ctx context.Context) (*entity.OutboxStats, error)
+	// Retry - false, если сообщение не находится в статусе dead
+	Retry(ctx context.Context, id int64) (bool, error)
+	RetryAllDead(ctx context.Context) (int, error)
+	DeleteSent(ctx context.Context, keep time.Duration) (int, error)
+}
+
+type outboxRepo struct {
+	*postgres.Postgres
+}
+
+func NewOutboxRepo(pg *postgres.Postgres) (OutboxRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &outboxRepo{
+		pg,
+	}, nil
+}
+
+const outboxColumns = `id, chat_id, text, markup, coalesce(question_id, 0), status, attempts, next_attempt_at,
+	coalesce(last_error, ''), created_at, sent_at`
+
+func scanOutbox(row pgx.Row) (entity.OutboxMessage, error) {
+	var message entity.OutboxMessage
+	err := row.Scan(&message.ID, &message.ChatID, &message.Text, &message.Markup, &message.QuestionID, &message.Status,
+		&message.Attempts, &message.NextAttemptAt, &message.LastError, &message.CreatedAt, &message.SentAt)
+	return message, err
+}
+
+func (o *outboxRepo) collectRows(rows pgx.Rows) ([]entity.OutboxMessage, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.OutboxMessage, error) {
+		return scanOutbox(row)
+	})
+}
+
+func (o *outboxRepo) Create(ctx context.Context, message *entity.OutboxMessage) error {
+	query := `insert into outbox (chat_id, text, markup, question_id) values ($1, $2, $3, nullif($4, 0))`
+
+	_, err := o.DB(ctx).Exec(ctx, query, message.ChatID, message.Text, message.Markup, message.QuestionID)
+	return ErrorHandler(err)
+}
+
+func (o *outboxRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.OutboxMessage, error) {
+	query := `update outbox set attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+			where id in (
+				select id from outbox
+				where status = 'pending' and next_attempt_at <= now()
+				order by id
+				limit $1
+				for update skip locked
+			)
+			returning ` + outboxColumns
+
+	rows, err := o.Pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := o.collectRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	// returning не сохраняет порядок подзапроса, сообщения одному чату уходят в порядке создания
+	slices.SortFunc(messages, func(a, b entity.OutboxMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return messages, nil
+}
+
+func (o *outboxRepo) MarkSent(ctx context.Context, id int64) error {
+	query := `update outbox set status = 'sent', sent_at = now(), last_error = null where id = $1`
+
+	_, err := o.Pool.Exec(ctx, query, id)
+	return err
+}
+
+func (o *outboxRepo) Reschedule(ctx context.Context, id int64, lastError string, delay time.Duration) error {
+	query := `update outbox set last_error = $2, next_attempt_at = now() + make_interval(secs => $3) where id = $1`
+
+	_, err := o.Pool.Exec(ctx, query, id, lastError, delay.Seconds())
+	return err
+}
+
+func (o *outboxRepo) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `update outbox set status = 'dead', last_error = $2 where id = $1`
+
+	_, err := o.Pool.Exec(ctx, query, id, lastError)
+	return err
+}
+
+func (o *outboxRepo) GetDead(ctx context.Context, limit int) ([]entity.OutboxMessage, error) {
+	query := `select ` + outboxColumns + ` from outbox where status = 'dead' order by id desc limit $1`
+
+	rows, err := o.Pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return o.collectRows(rows)
+}
+
+func (o *outboxRepo) GetStats(ctx context.Context) (*entity.OutboxStats, error) {
+	query := `select count(*) filter (where status = 'pending'), count(*) filter (where status = 'dead') from outbox`
+	stats := new(entity.OutboxStats)
+
+	err := o.Pool.QueryRow(ctx, query).Scan(&stats.Pending, &stats.Dead)
+	return stats, err
+}
+
+func (o *outboxRepo) Retry(ctx context.Context, id int64) (bool, error) {
+	query := `update outbox set status = 'pending', attempts = 0, next_attempt_at = now()
+			where id = $1 and status = 'dead'`
+
+	tag, err := o.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (o *outboxRepo) RetryAllDead(ctx context.Context) (int, error) {
+	query := `update outbox set status = 'pending', attempts = 0, next_attempt_at = now() where status = 'dead'`
+
+	tag, err := o.Pool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// DeleteSent - удаляет сообщения, доставленные больше keep назад. sent_at пишется через now(),
+// поэтому граница считается по часам базы
+func (o *outboxRepo) DeleteSent(ctx context.Context, keep time.Duration) (int, error) {
+	query := `delete from outbox where status = 'sent' and sent_at < now() - make_interval(secs => $1)`
+
+	tag, err := o.Pool.Exec(ctx, query, keep.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
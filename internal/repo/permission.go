@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type PermissionRepo interface {
+	GetByRole(ctx context.Context, role entity.UserRole) ([]entity.Permission, error)
+	GetByUserID(ctx context.Context, userID int64) ([]entity.Permission, error)
+}
+
+type permissionRepo struct {
+	*postgres.Postgres
+}
+
+func NewPermissionRepo(pg *postgres.Postgres) (PermissionRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &permissionRepo{
+		pg,
+	}, nil
+}
+
+func (p *permissionRepo) collectRows(rows pgx.Rows) ([]entity.Permission, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Permission, error) {
+		var permission entity.Permission
+		err := row.Scan(&permission)
+		return permission, ErrorHandler(err)
+	})
+}
+
+func (p *permissionRepo) GetByRole(ctx context.Context, role entity.UserRole) ([]entity.Permission, error) {
+	query := `select permission from role_permission where role = $1`
+
+	rows, err := p.Pool.Query(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	return p.collectRows(rows)
+}
+
+func (p *permissionRepo) GetByUserID(ctx context.Context, userID int64) ([]entity.Permission, error) {
+	query := `select rp.permission from role_permission rp
+			join "user" u on u.user_role = rp.role
+			where u.id = $1`
+
+	rows, err := p.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return p.collectRows(rows)
+}
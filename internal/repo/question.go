@@ -0,0 +1,439 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type QuestionRepo interface {
+	Create(ctx context.Context, question *entity.Question) (int, error)
+
+	GetByID(ctx context.Context, id int) (*entity.Question, error)
+	GetByNotifyMessage(ctx context.Context, chatID int64, messageID int) (*entity.Question, error)
+	GetAll(ctx context.Context) ([]entity.Question, error)
+	GetCreatedBetween(ctx context.Context, from, to time.Time) ([]entity.Question, error)
+	CountByStatus(ctx context.Context, statuses ...entity.QuestionStatus) (int, error)
+	GetOpenByAssignee(ctx context.Context, assigneeID int64) ([]entity.Question, error)
+	// GetList - limit основных вопросов в порядке sort, tagID = 0 означает без фильтра по тегу
+	GetList(ctx context.Context, tagID int, sort entity.QuestionSort, limit int) ([]entity.Question, error)
+	// GetForVoting - случайные проверенные вопросы без ответа, за которые userID еще не голосовал
+	GetForVoting(ctx context.Context, userID int64, limit int) ([]entity.Question, error)
+
+	// FindSimilar - основные вопросы после since, похожие на text не меньше minSimilarity, кроме exclude
+	FindSimilar(ctx context.Context, text string, exclude []int, since time.Time, minSimilarity float64, openOnly bool, limit int) ([]entity.SimilarQuestion, error)
+	GetClusterMembers(ctx context.Context, rootID int) ([]entity.Question, error)
+
+	// Search - полнотекстовый поиск по вопросам и ответам, возвращает страницу и общее число найденных
+	Search(ctx context.Context, query string, offset, limit int) ([]entity.SearchResult, int, error)
+	// SetCluster - делает id и его дубликаты дубликатами rootID, rootID = 0 отделяет вопрос
+	SetCluster(ctx context.Context, id int, rootID int) error
+
+	UpdateStatus(ctx context.Context, id int, status entity.QuestionStatus) error
+	// UpdateOpenStatus - как UpdateStatus, но только для открытого вопроса. false - вопрос уже закрыт
+	UpdateOpenStatus(ctx context.Context, id int, status entity.QuestionStatus) (bool, error)
+	// UpdateAnswer - answered_at хранит время первого ответа, повторный ответ после уточнения его не меняет
+	UpdateAnswer(ctx context.Context, id int, answer string, answeredBy int64) error
+	UpdateNotifyMessage(ctx context.Context, id int, chatID int64, messageID int) error
+
+	UpdateFirstAction(ctx context.Context, id int, adminID int64) error
+	// UpdatePublished - возвращает время публикации, записанное в базе
+	UpdatePublished(ctx context.Context, id int) (time.Time, error)
+	GetOverdue(ctx context.Context, timeout time.Duration) ([]entity.Question, error)
+	UpdateSLAAlerted(ctx context.Context, id int) error
+	GetResponseStats(ctx context.Context, from, to time.Time, tagID int) ([]entity.ResponseStats, error)
+
+	Claim(ctx context.Context, id int, assigneeID int64) (bool, error)
+	// Assign - передает вопрос assigneeID, если он свободен или закреплен за actorID. override снимает
+	// это условие. false - вопрос уже у другого администратора
+	Assign(ctx context.Context, id int, assigneeID int64, actorID int64, override bool) (bool, error)
+	Release(ctx context.Context, id int) error
+	ReleaseIdle(ctx context.Context, ttl time.Duration) ([]entity.Question, error)
+}
+
+type questionRepo struct {
+	*postgres.Postgres
+}
+
+func NewQuestionRepo(pg *postgres.Postgres) (QuestionRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &questionRepo{
+		pg,
+	}, nil
+}
+
+// questionVotes - голоса за вопрос вместе с голосами за его дубликаты
+const questionVotes = `(select count(*) from question_vote v join question d on d.id = v.question_id
+	where d.id = question.id or d.cluster_id = question.id)`
+
+const questionColumns = `id, user_id, question, is_checked, status, created_at, coalesce(answer, ''),
+	coalesce(answered_by, 0), coalesce(notify_chat_id, 0), coalesce(notify_message_id, 0), coalesce(assignee_id, 0),
+	claimed_at, first_action_at, coalesce(first_action_by, 0), checked_at, answered_at, published_at,
+	coalesce(cluster_id, 0), (select count(*) from question d where d.cluster_id = question.id),
+	` + questionVotes
+
+func (q *questionRepo) collectRow(row pgx.Row) (*entity.Question, error) {
+	var question entity.Question
+	err := row.Scan(&question.ID, &question.UserID, &question.Question, &question.IsChecked, &question.Status,
+		&question.CreatedAt, &question.Answer, &question.AnsweredBy, &question.NotifyChatID, &question.NotifyMessageID,
+		&question.AssigneeID, &question.ClaimedAt, &question.FirstActionAt, &question.FirstActionBy, &question.CheckedAt,
+		&question.AnsweredAt, &question.PublishedAt, &question.ClusterID, &question.Duplicates, &question.Votes)
+	if checkErr := ErrorHandler(err); checkErr != nil {
+		return nil, checkErr
+	}
+
+	return &question, err
+}
+
+func (q *questionRepo) collectRows(rows pgx.Rows) ([]entity.Question, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Question, error) {
+		question, err := q.collectRow(row)
+		if err != nil {
+			return entity.Question{}, err
+		}
+		return *question, nil
+	})
+}
+
+func (q *questionRepo) Create(ctx context.Context, question *entity.Question) (int, error) {
+	query := `insert into question (user_id, question, status, created_at) values ($1,$2,$3,$4) returning id`
+	var id int
+
+	err := q.DB(ctx).QueryRow(ctx, query, question.UserID, question.Question, question.Status, question.CreatedAt).Scan(&id)
+	return id, err
+}
+
+func (q *questionRepo) GetByID(ctx context.Context, id int) (*entity.Question, error) {
+	query := `select ` + questionColumns + ` from question where id = $1`
+
+	row := q.Pool.QueryRow(ctx, query, id)
+	return q.collectRow(row)
+}
+
+func (q *questionRepo) GetByNotifyMessage(ctx context.Context, chatID int64, messageID int) (*entity.Question, error) {
+	query := `select ` + questionColumns + ` from question where notify_chat_id = $1 and notify_message_id = $2`
+
+	row := q.Pool.QueryRow(ctx, query, chatID, messageID)
+	return q.collectRow(row)
+}
+
+func (q *questionRepo) GetAll(ctx context.Context) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question order by id`
+
+	rows, err := q.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) GetCreatedBetween(ctx context.Context, from, to time.Time) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question where created_at >= $1 and created_at < $2 order by id`
+
+	rows, err := q.Pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) CountByStatus(ctx context.Context, statuses ...entity.QuestionStatus) (int, error) {
+	query := `select count(*) from question where status = any($1)`
+	var count int
+
+	values := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		values = append(values, string(status))
+	}
+
+	err := q.Pool.QueryRow(ctx, query, values).Scan(&count)
+	return count, ErrorHandler(err)
+}
+
+func (q *questionRepo) UpdateStatus(ctx context.Context, id int, status entity.QuestionStatus) error {
+	query := `update question set status = $1, is_checked = is_checked or $1 <> 'new',
+			checked_at = case when $1 = 'checked' then coalesce(checked_at, now()) else checked_at end
+			where id = $2`
+
+	_, err := q.DB(ctx).Exec(ctx, query, status, id)
+	return err
+}
+
+func (q *questionRepo) UpdateOpenStatus(ctx context.Context, id int, status entity.QuestionStatus) (bool, error) {
+	query := `update question set status = $1, is_checked = is_checked or $1 <> 'new',
+			checked_at = case when $1 = 'checked' then coalesce(checked_at, now()) else checked_at end
+			where id = $2 and status in ('new', 'checked')`
+
+	tag, err := q.DB(ctx).Exec(ctx, query, status, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (q *questionRepo) UpdateAnswer(ctx context.Context, id int, answer string, answeredBy int64) error {
+	query := `update question set answer = $1, answered_by = $2, status = 'answered', is_checked = true,
+			answered_at = coalesce(answered_at, now())
+			where id = $3`
+
+	_, err := q.DB(ctx).Exec(ctx, query, answer, answeredBy, id)
+	return err
+}
+
+func (q *questionRepo) UpdateNotifyMessage(ctx context.Context, id int, chatID int64, messageID int) error {
+	query := `update question set notify_chat_id = $1, notify_message_id = $2 where id = $3`
+
+	_, err := q.Pool.Exec(ctx, query, chatID, messageID, id)
+	return err
+}
+
+func (q *questionRepo) GetOpenByAssignee(ctx context.Context, assigneeID int64) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question
+			where assignee_id = $1 and status in ('new', 'checked')
+			order by claimed_at`
+
+	rows, err := q.Pool.Query(ctx, query, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) GetList(ctx context.Context, tagID int, sort entity.QuestionSort, limit int) ([]entity.Question, error) {
+	order := `created_at desc`
+	if sort == entity.SortPopular {
+		order = questionVotes + ` desc, created_at desc`
+	}
+
+	query := `select ` + questionColumns + ` from question
+			where cluster_id is null
+				and ($1 = 0 or exists (select 1 from question_tag qt where qt.question_id = question.id and qt.tag_id = $1))
+			order by ` + order + ` limit $2`
+
+	rows, err := q.Pool.Query(ctx, query, tagID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) FindSimilar(ctx context.Context, text string, exclude []int, since time.Time, minSimilarity float64, openOnly bool, limit int) ([]entity.SimilarQuestion, error) {
+	query := `select ` + questionColumns + `, similarity(question, $1)::float8 as sml from question
+			where question % $1 and similarity(question, $1) >= $4
+				and cluster_id is null and id <> all($2) and created_at >= $3
+				and (not $5 or status in ('new', 'checked'))
+			order by sml desc limit $6`
+
+	rows, err := q.Pool.Query(ctx, query, text, exclude, since, minSimilarity, openOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.SimilarQuestion, error) {
+		var similar entity.SimilarQuestion
+		err := row.Scan(&similar.ID, &similar.UserID, &similar.Question.Question, &similar.IsChecked, &similar.Status,
+			&similar.CreatedAt, &similar.Answer, &similar.AnsweredBy, &similar.NotifyChatID, &similar.NotifyMessageID,
+			&similar.AssigneeID, &similar.ClaimedAt, &similar.FirstActionAt, &similar.FirstActionBy, &similar.CheckedAt,
+			&similar.AnsweredAt, &similar.PublishedAt, &similar.ClusterID, &similar.Duplicates, &similar.Votes, &similar.Similarity)
+		return similar, err
+	})
+}
+
+func (q *questionRepo) GetClusterMembers(ctx context.Context, rootID int) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question where cluster_id = $1 order by created_at`
+
+	rows, err := q.Pool.Query(ctx, query, rootID)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) SetCluster(ctx context.Context, id int, rootID int) error {
+	query := `update question set cluster_id = nullif($2, 0) where id = $1 or cluster_id = $1`
+
+	_, err := q.Pool.Exec(ctx, query, id, rootID)
+	return err
+}
+
+func (q *questionRepo) Search(ctx context.Context, query string, offset, limit int) ([]entity.SearchResult, int, error) {
+	var total int
+	countQuery := `select count(*) from question where search_vector @@ websearch_to_tsquery('russian', $1)`
+	if err := q.Pool.QueryRow(ctx, countQuery, query).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	searchQuery := `select ` + questionColumns + `,
+				ts_headline('russian', question, websearch_to_tsquery('russian', $1), $4),
+				ts_rank(search_vector, websearch_to_tsquery('russian', $1))::float8 as rank
+			from question
+			where search_vector @@ websearch_to_tsquery('russian', $1)
+			order by rank desc, created_at desc
+			offset $2 limit $3`
+
+	headlineOptions := "StartSel=" + entity.SnippetStart + ", StopSel=" + entity.SnippetStop +
+		", MaxWords=25, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+
+	rows, err := q.Pool.Query(ctx, searchQuery, query, offset, limit, headlineOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.SearchResult, error) {
+		var result entity.SearchResult
+		err := row.Scan(&result.ID, &result.UserID, &result.Question.Question, &result.IsChecked, &result.Status,
+			&result.CreatedAt, &result.Answer, &result.AnsweredBy, &result.NotifyChatID, &result.NotifyMessageID,
+			&result.AssigneeID, &result.ClaimedAt, &result.FirstActionAt, &result.FirstActionBy, &result.CheckedAt,
+			&result.AnsweredAt, &result.PublishedAt, &result.ClusterID, &result.Duplicates, &result.Votes, &result.Snippet, &result.Rank)
+		return result, err
+	})
+	return results, total, err
+}
+
+func (q *questionRepo) GetForVoting(ctx context.Context, userID int64, limit int) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question
+			where status = 'checked' and cluster_id is null and user_id <> $1
+				and not exists (select 1 from question_vote v where v.question_id = question.id and v.user_id = $1)
+			order by random() limit $2`
+
+	rows, err := q.Pool.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+// Claim - атомарно закрепляет вопрос за assigneeID, false - вопрос уже у другого администратора
+func (q *questionRepo) Claim(ctx context.Context, id int, assigneeID int64) (bool, error) {
+	query := `update question set assignee_id = $1, claimed_at = now()
+			where id = $2 and (assignee_id is null or assignee_id = $1)`
+
+	tag, err := q.Pool.Exec(ctx, query, assigneeID, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (q *questionRepo) Assign(ctx context.Context, id int, assigneeID int64, actorID int64, override bool) (bool, error) {
+	query := `update question set assignee_id = $1, claimed_at = now()
+			where id = $2 and (assignee_id is null or assignee_id = $3 or $4)`
+
+	tag, err := q.Pool.Exec(ctx, query, assigneeID, id, actorID, override)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (q *questionRepo) Release(ctx context.Context, id int) error {
+	query := `update question set assignee_id = null, claimed_at = null where id = $1`
+
+	_, err := q.Pool.Exec(ctx, query, id)
+	return err
+}
+
+// ReleaseIdle - снимает закрепление с неотвеченных вопросов, взятых больше ttl назад.
+// claimed_at пишется через now(), поэтому и граница считается по часам базы
+func (q *questionRepo) ReleaseIdle(ctx context.Context, ttl time.Duration) ([]entity.Question, error) {
+	query := `update question set assignee_id = null, claimed_at = null
+			where assignee_id is not null and claimed_at < now() - make_interval(secs => $1) and status in ('new', 'checked')
+			returning ` + questionColumns
+
+	rows, err := q.Pool.Query(ctx, query, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+// UpdateFirstAction - фиксирует только первое действие администратора над вопросом
+func (q *questionRepo) UpdateFirstAction(ctx context.Context, id int, adminID int64) error {
+	query := `update question set first_action_at = now(), first_action_by = $1
+			where id = $2 and first_action_at is null`
+
+	_, err := q.Pool.Exec(ctx, query, adminID, id)
+	return err
+}
+
+func (q *questionRepo) UpdatePublished(ctx context.Context, id int) (time.Time, error) {
+	query := `update question set published_at = coalesce(published_at, now()) where id = $1 returning published_at`
+
+	var publishedAt time.Time
+	err := q.Pool.QueryRow(ctx, query, id).Scan(&publishedAt)
+	return publishedAt, err
+}
+
+// GetOverdue - открытые вопросы старше timeout, о которых еще не было оповещения
+func (q *questionRepo) GetOverdue(ctx context.Context, timeout time.Duration) ([]entity.Question, error) {
+	query := `select ` + questionColumns + ` from question
+			where created_at < now() - make_interval(secs => $1) and status in ('new', 'checked') and sla_alerted_at is null and cluster_id is null
+			order by id`
+
+	rows, err := q.Pool.Query(ctx, query, timeout.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return q.collectRows(rows)
+}
+
+func (q *questionRepo) UpdateSLAAlerted(ctx context.Context, id int) error {
+	query := `update question set sla_alerted_at = now() where id = $1`
+
+	_, err := q.DB(ctx).Exec(ctx, query, id)
+	return err
+}
+
+// GetResponseStats - среднее время до первого действия и до ответа по администраторам
+// для вопросов, заданных в [from, to). Первая строка с admin_id = 0 - итог по всем.
+// tagID = 0 означает без фильтра по тегу
+func (q *questionRepo) GetResponseStats(ctx context.Context, from, to time.Time, tagID int) ([]entity.ResponseStats, error) {
+	query := `with period as (
+				select * from question where created_at >= $1 and created_at < $2
+					and ($3 = 0 or exists (select 1 from question_tag qt where qt.question_id = question.id and qt.tag_id = $3))
+			),
+			first_action as (
+				select first_action_by as admin_id, count(*) as cnt,
+					avg(extract(epoch from first_action_at - created_at)) as avg_seconds
+				from period where first_action_at is not null
+				group by grouping sets ((first_action_by), ())
+			),
+			answer as (
+				select answered_by as admin_id, count(*) as cnt,
+					avg(extract(epoch from answered_at - created_at)) as avg_seconds
+				from period where answered_at is not null
+				group by grouping sets ((answered_by), ())
+			)
+			select coalesce(f.admin_id, a.admin_id, 0), coalesce(u.tg_username, ''),
+				coalesce(f.cnt, 0), coalesce(f.avg_seconds, 0)::float8,
+				coalesce(a.cnt, 0), coalesce(a.avg_seconds, 0)::float8
+			from first_action f
+			full join answer a on coalesce(f.admin_id, 0) = coalesce(a.admin_id, 0)
+			left join "user" u on u.id = coalesce(f.admin_id, a.admin_id)
+			order by coalesce(f.admin_id, a.admin_id, 0) <> 0, coalesce(a.cnt, 0) desc`
+
+	rows, err := q.Pool.Query(ctx, query, from, to, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ResponseStats, error) {
+		var (
+			stats               entity.ResponseStats
+			firstSec, answerSec float64
+		)
+		err := row.Scan(&stats.AdminID, &stats.AdminUsername, &stats.FirstActions, &firstSec, &stats.Answers, &answerSec)
+		stats.AvgFirstAction = time.Duration(firstSec * float64(time.Second))
+		stats.AvgAnswer = time.Duration(answerSec * float64(time.Second))
+		return stats, err
+	})
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type RelayRepo interface {
+	Create(ctx context.Context, topic *entity.RelayTopic) error
+	GetByUser(ctx context.Context, chatID int64, userID int64) (*entity.RelayTopic, error)
+	GetByThread(ctx context.Context, chatID int64, threadID int) (*entity.RelayTopic, error)
+	SetOpen(ctx context.Context, chatID int64, userID int64, isOpen bool) error
+
+	// SaveMessage - сообщение messageID в теме группы поддержки относится к переписке с userID
+	SaveMessage(ctx context.Context, chatID int64, messageID int, userID int64) error
+	GetMessageUser(ctx context.Context, chatID int64, messageID int) (int64, error)
+}
+
+type relayRepo struct {
+	*postgres.Postgres
+}
+
+func NewRelayRepo(pg *postgres.Postgres) (RelayRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &relayRepo{
+		pg,
+	}, nil
+}
+
+const relayColumns = `user_id, chat_id, thread_id, is_open, created_at, closed_at`
+
+func scanRelayTopic(row pgx.Row) (*entity.RelayTopic, error) {
+	var topic entity.RelayTopic
+	err := row.Scan(&topic.UserID, &topic.ChatID, &topic.ThreadID, &topic.IsOpen, &topic.CreatedAt, &topic.ClosedAt)
+	if err != nil {
+		return nil, ErrorHandler(err)
+	}
+	return &topic, nil
+}
+
+func (r *relayRepo) Create(ctx context.Context, topic *entity.RelayTopic) error {
+	query := `insert into relay_topic (user_id, chat_id, thread_id) values ($1, $2, $3)`
+
+	_, err := r.Pool.Exec(ctx, query, topic.UserID, topic.ChatID, topic.ThreadID)
+	return ErrorHandler(err)
+}
+
+func (r *relayRepo) GetByUser(ctx context.Context, chatID int64, userID int64) (*entity.RelayTopic, error) {
+	query := `select ` + relayColumns + ` from relay_topic where chat_id = $1 and user_id = $2`
+
+	return scanRelayTopic(r.Pool.QueryRow(ctx, query, chatID, userID))
+}
+
+func (r *relayRepo) GetByThread(ctx context.Context, chatID int64, threadID int) (*entity.RelayTopic, error) {
+	query := `select ` + relayColumns + ` from relay_topic where chat_id = $1 and thread_id = $2`
+
+	return scanRelayTopic(r.Pool.QueryRow(ctx, query, chatID, threadID))
+}
+
+func (r *relayRepo) SetOpen(ctx context.Context, chatID int64, userID int64, isOpen bool) error {
+	query := `update relay_topic set is_open = $3,
+				closed_at = case when $3 then null else now() end
+			where chat_id = $1 and user_id = $2`
+
+	_, err := r.Pool.Exec(ctx, query, chatID, userID, isOpen)
+	return err
+}
+
+func (r *relayRepo) SaveMessage(ctx context.Context, chatID int64, messageID int, userID int64) error {
+	query := `insert into relay_message (chat_id, message_id, user_id) values ($1, $2, $3)
+			on conflict do nothing`
+
+	_, err := r.Pool.Exec(ctx, query, chatID, messageID, userID)
+	return err
+}
+
+func (r *relayRepo) GetMessageUser(ctx context.Context, chatID int64, messageID int) (int64, error) {
+	query := `select user_id from relay_message where chat_id = $1 and message_id = $2`
+	var userID int64
+
+	err := r.Pool.QueryRow(ctx, query, chatID, messageID).Scan(&userID)
+	return userID, ErrorHandler(err)
+}
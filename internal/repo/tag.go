@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepo interface {
+	Create(ctx context.Context, name string) (int, error)
+	Delete(ctx context.Context, id int) error
+
+	GetAll(ctx context.Context) ([]entity.Tag, error)
+	GetByID(ctx context.Context, id int) (*entity.Tag, error)
+	GetByName(ctx context.Context, name string) (*entity.Tag, error)
+	GetByQuestion(ctx context.Context, questionID int) ([]entity.Tag, error)
+	GetAssignments(ctx context.Context, questionID int) ([]entity.QuestionTag, error)
+
+	AddToQuestion(ctx context.Context, questionID, tagID int) error
+	// AddAuto - тег, поставленный правилом, не перезаписывает уже стоящий тег
+	AddAuto(ctx context.Context, questionID, tagID, ruleID int, confidence float64) error
+	RemoveFromQuestion(ctx context.Context, questionID, tagID int) error
+}
+
+type tagRepo struct {
+	*postgres.Postgres
+}
+
+func NewTagRepo(pg *postgres.Postgres) (TagRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &tagRepo{
+		pg,
+	}, nil
+}
+
+func (t *tagRepo) collectRows(rows pgx.Rows) ([]entity.Tag, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Tag, error) {
+		var tag entity.Tag
+		err := row.Scan(&tag.ID, &tag.Name)
+		return tag, ErrorHandler(err)
+	})
+}
+
+func (t *tagRepo) Create(ctx context.Context, name string) (int, error) {
+	query := `insert into tag (name) values ($1) returning id`
+	var id int
+
+	err := t.Pool.QueryRow(ctx, query, name).Scan(&id)
+	return id, ErrorHandler(err)
+}
+
+func (t *tagRepo) Delete(ctx context.Context, id int) error {
+	query := `delete from tag where id = $1`
+
+	_, err := t.Pool.Exec(ctx, query, id)
+	return err
+}
+
+func (t *tagRepo) GetAll(ctx context.Context) ([]entity.Tag, error) {
+	query := `select id, name from tag order by name`
+
+	rows, err := t.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return t.collectRows(rows)
+}
+
+func (t *tagRepo) GetByID(ctx context.Context, id int) (*entity.Tag, error) {
+	query := `select id, name from tag where id = $1`
+	var tag entity.Tag
+
+	err := t.Pool.QueryRow(ctx, query, id).Scan(&tag.ID, &tag.Name)
+	if checkErr := ErrorHandler(err); checkErr != nil {
+		return nil, checkErr
+	}
+	return &tag, nil
+}
+
+func (t *tagRepo) GetByName(ctx context.Context, name string) (*entity.Tag, error) {
+	query := `select id, name from tag where name = $1`
+	var tag entity.Tag
+
+	err := t.Pool.QueryRow(ctx, query, name).Scan(&tag.ID, &tag.Name)
+	if checkErr := ErrorHandler(err); checkErr != nil {
+		return nil, checkErr
+	}
+	return &tag, nil
+}
+
+func (t *tagRepo) GetByQuestion(ctx context.Context, questionID int) ([]entity.Tag, error) {
+	query := `select t.id, t.name from tag t
+			join question_tag qt on qt.tag_id = t.id
+			where qt.question_id = $1
+			order by t.name`
+
+	rows, err := t.Pool.Query(ctx, query, questionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.collectRows(rows)
+}
+
+func (t *tagRepo) GetAssignments(ctx context.Context, questionID int) ([]entity.QuestionTag, error) {
+	query := `select t.id, t.name, coalesce(qt.rule_id, 0), coalesce(qt.confidence, 0) from tag t
+			join question_tag qt on qt.tag_id = t.id
+			where qt.question_id = $1
+			order by t.name`
+
+	rows, err := t.Pool.Query(ctx, query, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.QuestionTag, error) {
+		var tag entity.QuestionTag
+		err := row.Scan(&tag.ID, &tag.Name, &tag.RuleID, &tag.Confidence)
+		return tag, ErrorHandler(err)
+	})
+}
+
+func (t *tagRepo) AddAuto(ctx context.Context, questionID, tagID, ruleID int, confidence float64) error {
+	query := `insert into question_tag (question_id, tag_id, rule_id, confidence) values ($1, $2, $3, $4)
+			on conflict do nothing`
+
+	_, err := t.Pool.Exec(ctx, query, questionID, tagID, ruleID, confidence)
+	return err
+}
+
+func (t *tagRepo) AddToQuestion(ctx context.Context, questionID, tagID int) error {
+	query := `insert into question_tag (question_id, tag_id) values ($1, $2) on conflict do nothing`
+
+	_, err := t.Pool.Exec(ctx, query, questionID, tagID)
+	return err
+}
+
+func (t *tagRepo) RemoveFromQuestion(ctx context.Context, questionID, tagID int) error {
+	query := `delete from question_tag where question_id = $1 and tag_id = $2`
+
+	_, err := t.Pool.Exec(ctx, query, questionID, tagID)
+	return err
+}
@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRuleRepo interface {
+	// Create - правило добавляется в конец списка
+	Create(ctx context.Context, rule *entity.TagRule) (int, error)
+	Delete(ctx context.Context, id int) error
+	GetAll(ctx context.Context) ([]entity.TagRule, error)
+	GetByID(ctx context.Context, id int) (*entity.TagRule, error)
+
+	CreateCorrection(ctx context.Context, correction *entity.TagCorrection) error
+	GetStats(ctx context.Context) ([]entity.TagRuleStats, error)
+}
+
+type tagRuleRepo struct {
+	*postgres.Postgres
+}
+
+func NewTagRuleRepo(pg *postgres.Postgres) (TagRuleRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &tagRuleRepo{
+		pg,
+	}, nil
+}
+
+const tagRuleColumns = `r.id, r.tag_id, t.name, r.kind, r.patterns, r.weight, r.position, coalesce(r.created_by, 0), r.created_at`
+
+func scanTagRule(row pgx.Row) (entity.TagRule, error) {
+	var rule entity.TagRule
+	err := row.Scan(&rule.ID, &rule.TagID, &rule.TagName, &rule.Kind, &rule.Patterns, &rule.Weight,
+		&rule.Position, &rule.CreatedBy, &rule.CreatedAt)
+	return rule, ErrorHandler(err)
+}
+
+func (t *tagRuleRepo) Create(ctx context.Context, rule *entity.TagRule) (int, error) {
+	query := `insert into tag_rule (tag_id, kind, patterns, weight, position, created_by)
+			values ($1, $2, $3, $4, (select coalesce(max(position), 0) + 1 from tag_rule), $5)
+			returning id`
+	var id int
+
+	err := t.Pool.QueryRow(ctx, query, rule.TagID, rule.Kind, rule.Patterns, rule.Weight, rule.CreatedBy).Scan(&id)
+	return id, ErrorHandler(err)
+}
+
+func (t *tagRuleRepo) Delete(ctx context.Context, id int) error {
+	query := `delete from tag_rule where id = $1`
+
+	_, err := t.Pool.Exec(ctx, query, id)
+	return err
+}
+
+func (t *tagRuleRepo) GetAll(ctx context.Context) ([]entity.TagRule, error) {
+	query := `select ` + tagRuleColumns + ` from tag_rule r
+			join tag t on t.id = r.tag_id
+			order by r.position`
+
+	rows, err := t.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.TagRule, error) {
+		return scanTagRule(row)
+	})
+}
+
+func (t *tagRuleRepo) GetByID(ctx context.Context, id int) (*entity.TagRule, error) {
+	query := `select ` + tagRuleColumns + ` from tag_rule r
+			join tag t on t.id = r.tag_id
+			where r.id = $1`
+
+	rule, err := scanTagRule(t.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (t *tagRuleRepo) CreateCorrection(ctx context.Context, correction *entity.TagCorrection) error {
+	query := `insert into tag_correction (question_id, tag_id, rule_id, action, admin_id)
+			values ($1, $2, nullif($3, 0), $4, $5)`
+
+	_, err := t.Pool.Exec(ctx, query, correction.QuestionID, correction.TagID, correction.RuleID,
+		correction.Action, correction.AdminID)
+	return err
+}
+
+// GetStats - снятые вручную теги удаляются из question_tag, поэтому учитываются в срабатываниях отдельно
+func (t *tagRuleRepo) GetStats(ctx context.Context) ([]entity.TagRuleStats, error) {
+	query := `select r.id,
+				(select count(*) from question_tag qt where qt.rule_id = r.id) +
+				(select count(*) from tag_correction c where c.rule_id = r.id and c.action = 'removed'),
+				(select count(*) from tag_correction c where c.rule_id = r.id and c.action = 'removed'),
+				(select count(*) from tag_correction c where c.tag_id = r.tag_id and c.action = 'added')
+			from tag_rule r
+			order by r.position`
+
+	rows, err := t.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.TagRuleStats, error) {
+		var stats entity.TagRuleStats
+		err := row.Scan(&stats.RuleID, &stats.Assigned, &stats.Removed, &stats.Missed)
+		return stats, err
+	})
+}
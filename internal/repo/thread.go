@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type ThreadRepo interface {
+	Create(ctx context.Context, message *entity.ThreadMessage) error
+	GetByQuestion(ctx context.Context, questionID int) ([]entity.ThreadMessage, error)
+
+	// LinkMessage - сообщение бота messageID в чате chatID относится к вопросу questionID
+	LinkMessage(ctx context.Context, chatID int64, messageID int, questionID int) error
+	GetLinkedQuestion(ctx context.Context, chatID int64, messageID int) (int, error)
+}
+
+type threadRepo struct {
+	*postgres.Postgres
+}
+
+func NewThreadRepo(pg *postgres.Postgres) (ThreadRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &threadRepo{
+		pg,
+	}, nil
+}
+
+func (t *threadRepo) Create(ctx context.Context, message *entity.ThreadMessage) error {
+	query := `insert into question_thread (question_id, kind, author_id, text) values ($1, $2, $3, $4)`
+
+	_, err := t.DB(ctx).Exec(ctx, query, message.QuestionID, message.Kind, message.AuthorID, message.Text)
+	return ErrorHandler(err)
+}
+
+func (t *threadRepo) GetByQuestion(ctx context.Context, questionID int) ([]entity.ThreadMessage, error) {
+	query := `select id, question_id, kind, author_id, text, created_at from question_thread
+			where question_id = $1
+			order by created_at, id`
+
+	rows, err := t.Pool.Query(ctx, query, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ThreadMessage, error) {
+		var message entity.ThreadMessage
+		err := row.Scan(&message.ID, &message.QuestionID, &message.Kind, &message.AuthorID, &message.Text, &message.CreatedAt)
+		return message, err
+	})
+}
+
+func (t *threadRepo) LinkMessage(ctx context.Context, chatID int64, messageID int, questionID int) error {
+	query := `insert into question_message (chat_id, message_id, question_id) values ($1, $2, $3)
+			on conflict (chat_id, message_id) do update set question_id = excluded.question_id`
+
+	_, err := t.Pool.Exec(ctx, query, chatID, messageID, questionID)
+	return ErrorHandler(err)
+}
+
+func (t *threadRepo) GetLinkedQuestion(ctx context.Context, chatID int64, messageID int) (int, error) {
+	query := `select question_id from question_message where chat_id = $1 and message_id = $2`
+	var id int
+
+	err := t.Pool.QueryRow(ctx, query, chatID, messageID).Scan(&id)
+	return id, ErrorHandler(err)
+}
@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+)
+
+// Transactor - объединяет вызовы репозиториев в одну транзакцию, если они пишут через DB(ctx)
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func NewTransactor(pg *postgres.Postgres) (Transactor, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return pg, nil
+}
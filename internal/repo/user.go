@@ -6,6 +6,7 @@ import (
 	"github.com/Enthreeka/tg-question-bot/internal/entity"
 	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
 	"github.com/jackc/pgx/v5"
+	"time"
 )
 
 type UserRepo interface {
@@ -15,11 +16,14 @@ type UserRepo interface {
 	GetAllUsers(ctx context.Context) ([]entity.User, error)
 	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	CountCreatedBetweenBySource(ctx context.Context, from, to time.Time) (map[string]int, error)
+	GetUsersWithPermission(ctx context.Context, permission entity.Permission) ([]entity.User, error)
 
 	IsUserExistByUsernameTg(ctx context.Context, usernameTg string) (bool, error)
 	IsUserExistByUserID(ctx context.Context, userID int64) (bool, error)
 
 	UpdateRoleByUsername(ctx context.Context, role entity.UserRole, username string) error
+	UpdateBanned(ctx context.Context, userID int64, isBanned bool) error
 }
 
 type userRepo struct {
@@ -38,7 +42,7 @@ func NewUserRepo(pg *postgres.Postgres) (UserRepo, error) {
 
 func (u *userRepo) collectRow(row pgx.Row) (*entity.User, error) {
 	var user entity.User
-	err := row.Scan(&user.ID, &user.TGUsername, &user.CreatedAt, &user.ChannelFrom, &user.UserRole)
+	err := row.Scan(&user.ID, &user.TGUsername, &user.CreatedAt, &user.ChannelFrom, &user.UserRole, &user.IsBanned)
 	if checkErr := ErrorHandler(err); checkErr != nil {
 		return nil, checkErr
 	}
@@ -104,7 +108,7 @@ func (u *userRepo) IsUserExistByUsernameTg(ctx context.Context, usernameTg strin
 }
 
 func (u *userRepo) GetAllAdmin(ctx context.Context) ([]entity.User, error) {
-	query := `select * from "user" where user_role = 'admin' or user_role = 'superAdmin'`
+	query := `select * from "user" where user_role <> 'user'`
 
 	rows, err := u.Pool.Query(ctx, query)
 	if err != nil {
@@ -124,3 +128,50 @@ func (u *userRepo) IsUserExistByUserID(ctx context.Context, userID int64) (bool,
 
 	return isExist, nil
 }
+
+func (u *userRepo) UpdateBanned(ctx context.Context, userID int64, isBanned bool) error {
+	query := `update "user" set is_banned = $1 where id = $2`
+
+	_, err := u.Pool.Exec(ctx, query, isBanned, userID)
+	return err
+}
+
+// CountCreatedBetweenBySource - количество новых пользователей по каналу, из которого они пришли
+func (u *userRepo) CountCreatedBetweenBySource(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	query := `select coalesce(channel_from, ''), count(*) from "user"
+			where created_at >= $1 and created_at < $2
+			group by 1`
+
+	rows, err := u.Pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]int)
+	for rows.Next() {
+		var (
+			source string
+			count  int
+		)
+		if err := rows.Scan(&source, &count); err != nil {
+			return nil, err
+		}
+		result[source] = count
+	}
+
+	return result, rows.Err()
+}
+
+func (u *userRepo) GetUsersWithPermission(ctx context.Context, permission entity.Permission) ([]entity.User, error) {
+	query := `select u.* from "user" u
+			join role_permission rp on rp.role = u.user_role
+			where rp.permission = $1
+			order by u.tg_username`
+
+	rows, err := u.Pool.Query(ctx, query, permission)
+	if err != nil {
+		return nil, err
+	}
+	return u.collectRows(rows)
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+)
+
+type VoteRepo interface {
+	// Create - false, если пользователь уже голосовал за вопрос
+	Create(ctx context.Context, questionID int, userID int64) (bool, error)
+}
+
+type voteRepo struct {
+	*postgres.Postgres
+}
+
+func NewVoteRepo(pg *postgres.Postgres) (VoteRepo, error) {
+	if pg == nil {
+		return nil, errors.New("postgres repository is nil")
+	}
+
+	return &voteRepo{
+		pg,
+	}, nil
+}
+
+func (v *voteRepo) Create(ctx context.Context, questionID int, userID int64) (bool, error) {
+	query := `insert into question_vote (question_id, user_id) values ($1, $2) on conflict do nothing`
+
+	tag, err := v.Pool.Exec(ctx, query, questionID, userID)
+	if err != nil {
+		return false, ErrorHandler(err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"time"
+)
+
+const AuditPageSize = 10
+
+type AuditService interface {
+	Log(ctx context.Context, actorID int64, action entity.AuditAction, target string, diff entity.AuditDiff)
+
+	GetPage(ctx context.Context, page int) ([]entity.AuditLog, int, error)
+	GetAll(ctx context.Context) ([]entity.AuditLog, error)
+}
+
+type auditService struct {
+	auditRepo repo.AuditRepo
+	log       *logger.Logger
+}
+
+func NewAuditService(auditRepo repo.AuditRepo, log *logger.Logger) (AuditService, error) {
+	if auditRepo == nil {
+		return nil, errors.New("auditRepo is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &auditService{
+		auditRepo: auditRepo,
+		log:       log,
+	}, nil
+}
+
+// Log - запись в журнал не должна прерывать основное действие, поэтому ошибка только логируется
+func (a *auditService) Log(ctx context.Context, actorID int64, action entity.AuditAction, target string, diff entity.AuditDiff) {
+	auditLog := &entity.AuditLog{
+		ActorID:   actorID,
+		Action:    action,
+		Target:    target,
+		CreatedAt: time.Now().Local(),
+		Diff:      diff,
+	}
+
+	if err := a.auditRepo.Create(ctx, auditLog); err != nil {
+		a.log.Error("auditRepo.Create: failed to write audit log %s: %v", auditLog.String(), err)
+	}
+}
+
+// GetPage - возвращает записи страницы page (с нуля) и общее количество страниц
+func (a *auditService) GetPage(ctx context.Context, page int) ([]entity.AuditLog, int, error) {
+	count, err := a.auditRepo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pages := (count + AuditPageSize - 1) / AuditPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	logs, err := a.auditRepo.GetPage(ctx, AuditPageSize, page*AuditPageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, pages, nil
+}
+
+func (a *auditService) GetAll(ctx context.Context) ([]entity.AuditLog, error) {
+	return a.auditRepo.GetAll(ctx)
+}
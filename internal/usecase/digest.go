@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/text"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	digestKeywordsLimit = 10
+	digestCheckInterval = time.Minute
+)
+
+type DigestService interface {
+	// Run - блокирующий планировщик, отправляет дайджесты в настроенное время до отмены ctx
+	Run(ctx context.Context)
+
+	Build(ctx context.Context, kind entity.DigestKind, from, to time.Time) (*entity.Digest, error)
+	Format(digest *entity.Digest) string
+
+	GetSubscriptions(ctx context.Context, userID int64) ([]entity.DigestKind, error)
+	ToggleSubscription(ctx context.Context, userID int64, kind entity.DigestKind) (bool, error)
+}
+
+type digestService struct {
+	digestRepo      repo.DigestRepo
+	userService     UserService
+	questionService QuestionService
+	outboxService   OutboxService
+	transactor      repo.Transactor
+	log             *logger.Logger
+
+	// sendAt - смещение от начала суток, отрицательное значение отключает рассылку
+	sendAt    time.Duration
+	weeklyDay time.Weekday
+}
+
+func NewDigestService(
+	digestRepo repo.DigestRepo,
+	userService UserService,
+	questionService QuestionService,
+	outboxService OutboxService,
+	transactor repo.Transactor,
+	log *logger.Logger,
+	sendAt time.Duration,
+	weeklyDay time.Weekday,
+) (DigestService, error) {
+	if digestRepo == nil {
+		return nil, errors.New("digestRepo is nil")
+	}
+	if userService == nil {
+		return nil, errors.New("userService is nil")
+	}
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if outboxService == nil {
+		return nil, errors.New("outboxService is nil")
+	}
+	if transactor == nil {
+		return nil, errors.New("transactor is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &digestService{
+		digestRepo:      digestRepo,
+		userService:     userService,
+		questionService: questionService,
+		outboxService:   outboxService,
+		transactor:      transactor,
+		log:             log,
+		sendAt:          sendAt,
+		weeklyDay:       weeklyDay,
+	}, nil
+}
+
+func (d *digestService) Run(ctx context.Context) {
+	if d.sendAt < 0 {
+		d.log.Info("Digest is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		d.tick(ctx, time.Now().Local())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *digestService) tick(ctx context.Context, now time.Time) {
+	year, month, day := now.Date()
+	slot := time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Add(d.sendAt)
+	if now.Before(slot) {
+		return
+	}
+
+	d.sendIfDue(ctx, entity.DigestDaily, slot)
+	if now.Weekday() == d.weeklyDay {
+		d.sendIfDue(ctx, entity.DigestWeekly, slot)
+	}
+}
+
+// sendIfDue - время последней отправки хранится в БД, поэтому перезапуск не приводит к повторной рассылке
+func (d *digestService) sendIfDue(ctx context.Context, kind entity.DigestKind, slot time.Time) {
+	lastSent, err := d.digestRepo.GetLastSent(ctx, kind)
+	if err != nil {
+		d.log.Error("digestRepo.GetLastSent: failed to get last %s digest: %v", kind, err)
+		return
+	}
+
+	if !lastSent.Before(slot) {
+		return
+	}
+
+	from := lastSent
+	if from.IsZero() {
+		from = slot.Add(-kind.Period())
+	}
+
+	digest, err := d.Build(ctx, kind, from, slot)
+	if err != nil {
+		d.log.Error("failed to build %s digest: %v", kind, err)
+		return
+	}
+
+	subscribers, err := d.digestRepo.GetSubscribers(ctx, kind)
+	if err != nil {
+		d.log.Error("digestRepo.GetSubscribers: failed to get %s subscribers: %v", kind, err)
+		return
+	}
+
+	// рассылка и отметка о ней сохраняются вместе, поэтому дайджест не уйдет дважды и не потеряется
+	digestText := d.Format(digest)
+	err = d.transactor.WithTx(ctx, func(ctx context.Context) error {
+		for _, userID := range subscribers {
+			if err := d.outboxService.Enqueue(ctx, userID, nil, digestText, 0); err != nil {
+				return err
+			}
+		}
+
+		if err := d.digestRepo.UpdateLastSent(ctx, kind, slot); err != nil {
+			d.log.Error("digestRepo.UpdateLastSent: failed to save %s digest time: %v", kind, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		d.log.Error("failed to enqueue %s digest: %v", kind, err)
+		return
+	}
+	d.outboxService.Notify()
+
+	d.log.Info("%s queued for %d admins", kind.Title(), len(subscribers))
+}
+
+func (d *digestService) Build(ctx context.Context, kind entity.DigestKind, from, to time.Time) (*entity.Digest, error) {
+	questions, err := d.questionService.GetQuestionsBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	unanswered, err := d.questionService.CountUnanswered(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	newUsers, err := d.userService.GetNewUsersBySource(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(questions))
+	for _, q := range questions {
+		texts = append(texts, q.Question)
+	}
+
+	return &entity.Digest{
+		Kind:         kind,
+		From:         from,
+		To:           to,
+		NewQuestions: len(questions),
+		Unanswered:   unanswered,
+		NewUsers:     newUsers,
+		Keywords:     text.TopKeywords(texts, digestKeywordsLimit),
+	}, nil
+}
+
+func (d *digestService) Format(digest *entity.Digest) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<b>%s</b>\n%s — %s\n\n",
+		digest.Kind.Title(),
+		digest.From.Format("02.01.2006 15:04"),
+		digest.To.Format("02.01.2006 15:04")))
+	sb.WriteString(fmt.Sprintf("Новых вопросов: %d\n", digest.NewQuestions))
+	sb.WriteString(fmt.Sprintf("Без ответа всего: %d\n", digest.Unanswered))
+
+	total := 0
+	sources := make([]string, 0, len(digest.NewUsers))
+	for source, count := range digest.NewUsers {
+		total += count
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return digest.NewUsers[sources[i]] > digest.NewUsers[sources[j]]
+	})
+
+	sb.WriteString(fmt.Sprintf("\nНовых пользователей: %d\n", total))
+	for _, source := range sources {
+		title := source
+		if title == "" {
+			title = "без источника"
+		}
+		sb.WriteString(fmt.Sprintf("  • %s: %d\n", html.EscapeString(title), digest.NewUsers[source]))
+	}
+
+	if len(digest.Keywords) > 0 {
+		words := make([]string, 0, len(digest.Keywords))
+		for _, k := range digest.Keywords {
+			words = append(words, fmt.Sprintf("%s (%d)", html.EscapeString(k.Word), k.Count))
+		}
+		sb.WriteString("\nЧастые темы: " + strings.Join(words, ", "))
+	}
+
+	return sb.String()
+}
+
+func (d *digestService) GetSubscriptions(ctx context.Context, userID int64) ([]entity.DigestKind, error) {
+	return d.digestRepo.GetSubscriptions(ctx, userID)
+}
+
+// ToggleSubscription - возвращает новое состояние подписки
+func (d *digestService) ToggleSubscription(ctx context.Context, userID int64, kind entity.DigestKind) (bool, error) {
+	subscriptions, err := d.digestRepo.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range subscriptions {
+		if s == kind {
+			return false, d.digestRepo.Unsubscribe(ctx, userID, kind)
+		}
+	}
+
+	return true, d.digestRepo.Subscribe(ctx, userID, kind)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/text"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	"html"
+	"strings"
+)
+
+type FaqService interface {
+	// CreateFaq - patterns: признаки вопроса через ";", answer - текст ответа или ссылка на пост
+	CreateFaq(ctx context.Context, actorID int64, patterns string, answer string) (*entity.Faq, error)
+	DeleteFaq(ctx context.Context, actorID int64, id int) error
+	GetAll(ctx context.Context) ([]entity.Faq, []entity.FaqStats, error)
+
+	// Suggest - отправляет пользователю подходящий ответ из FAQ, false - подходящего ответа нет
+	Suggest(ctx context.Context, userID int64, question string) (bool, error)
+	// Resolve - фиксирует решение пользователя, при FaqSent вопрос уходит аналитикам
+	Resolve(ctx context.Context, userID int64, suggestionID int, outcome entity.FaqOutcome) error
+}
+
+type faqService struct {
+	faqRepo         repo.FaqRepo
+	userRepo        repo.UserRepo
+	questionService QuestionService
+	auditService    AuditService
+	log             *logger.Logger
+	tgMsg           customMsg.Message
+}
+
+func NewFaqService(
+	faqRepo repo.FaqRepo,
+	userRepo repo.UserRepo,
+	questionService QuestionService,
+	auditService AuditService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+) (FaqService, error) {
+	if faqRepo == nil {
+		return nil, errors.New("faqRepo is nil")
+	}
+	if userRepo == nil {
+		return nil, errors.New("userRepo is nil")
+	}
+	if questionService == nil {
+		return nil, errors.New("questionService is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &faqService{
+		faqRepo:         faqRepo,
+		userRepo:        userRepo,
+		questionService: questionService,
+		auditService:    auditService,
+		log:             log,
+		tgMsg:           tgMsg,
+	}, nil
+}
+
+func (f *faqService) CreateFaq(ctx context.Context, actorID int64, patterns string, answer string) (*entity.Faq, error) {
+	faq, err := parseFaq(patterns, answer)
+	if err != nil {
+		return nil, err
+	}
+	faq.CreatedBy = actorID
+
+	faq.ID, err = f.faqRepo.Create(ctx, faq)
+	if err != nil {
+		f.log.Error("faqRepo.Create: failed to create faq: %v", err)
+		return nil, err
+	}
+
+	f.auditService.Log(ctx, actorID, entity.AuditFaqCreate, faqTarget(faq.ID), entity.AuditDiff{
+		"patterns": {New: faq.Patterns},
+		"answer":   {New: faq.Answer},
+	})
+
+	return faq, nil
+}
+
+func (f *faqService) DeleteFaq(ctx context.Context, actorID int64, id int) error {
+	faq, err := f.faqRepo.GetByID(ctx, id)
+	if err != nil {
+		f.log.Error("faqRepo.GetByID: failed to get faq %d: %v", id, err)
+		return err
+	}
+
+	if err := f.faqRepo.Delete(ctx, id); err != nil {
+		f.log.Error("faqRepo.Delete: failed to delete faq %d: %v", id, err)
+		return err
+	}
+
+	f.auditService.Log(ctx, actorID, entity.AuditDelete, faqTarget(id), entity.AuditDiff{
+		"patterns": {Old: faq.Patterns},
+		"answer":   {Old: faq.Answer},
+	})
+
+	return nil
+}
+
+func (f *faqService) GetAll(ctx context.Context) ([]entity.Faq, []entity.FaqStats, error) {
+	faqs, err := f.faqRepo.GetAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats, err := f.faqRepo.GetStats(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return faqs, stats, nil
+}
+
+func (f *faqService) Suggest(ctx context.Context, userID int64, question string) (bool, error) {
+	user, err := f.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		f.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return false, err
+	}
+	// вопросы заблокированных пользователей отбрасывает QuestionService
+	if user.IsBanned {
+		return false, nil
+	}
+
+	faqs, err := f.faqRepo.GetAll(ctx)
+	if err != nil {
+		f.log.Error("faqRepo.GetAll: %v", err)
+		return false, err
+	}
+
+	faq := matchFaq(faqs, question)
+	if faq == nil {
+		return false, nil
+	}
+
+	suggestion := &entity.FaqSuggestion{
+		FaqID:    faq.ID,
+		UserID:   userID,
+		Question: question,
+	}
+	suggestion.ID, err = f.faqRepo.CreateSuggestion(ctx, suggestion)
+	if err != nil {
+		f.log.Error("faqRepo.CreateSuggestion: failed to save suggestion of faq %d: %v", faq.ID, err)
+		return false, err
+	}
+
+	suggestionMarkup := markup.FaqSuggestion(suggestion.ID)
+	if _, err := f.tgMsg.SendNewMessage(ctx, userID, &suggestionMarkup,
+		"Возможно, ответ здесь:\n\n"+html.EscapeString(faq.Answer)); err != nil {
+		f.log.Error("failed to send faq %d to user %d: %v", faq.ID, userID, err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (f *faqService) Resolve(ctx context.Context, userID int64, suggestionID int, outcome entity.FaqOutcome) error {
+	suggestion, err := f.faqRepo.GetSuggestion(ctx, suggestionID)
+	if err != nil {
+		f.log.Error("faqRepo.GetSuggestion: failed to get suggestion %d: %v", suggestionID, err)
+		return err
+	}
+	if suggestion.UserID != userID {
+		return customErr.ErrNotFound
+	}
+
+	// повторное нажатие не должно создавать второй вопрос
+	updated, err := f.faqRepo.SetOutcome(ctx, suggestionID, outcome)
+	if err != nil {
+		f.log.Error("faqRepo.SetOutcome: failed to resolve suggestion %d: %v", suggestionID, err)
+		return err
+	}
+	if !updated {
+		return nil
+	}
+
+	f.log.Info("FAQ suggestion %d of faq %d resolved by user %d: %s", suggestionID, suggestion.FaqID, userID, outcome)
+
+	if outcome == entity.FaqSent {
+		return f.questionService.CreateQuestion(ctx, userID, suggestion.Question)
+	}
+	return nil
+}
+
+// matchFaq - запись с наибольшим числом найденных признаков, при равенстве - более ранняя
+func matchFaq(faqs []entity.Faq, question string) *entity.Faq {
+	var (
+		stems = text.Stems(question)
+		best  *entity.Faq
+		most  int
+	)
+	for i := range faqs {
+		hits := 0
+		for _, pattern := range faqs[i].Patterns {
+			if text.ContainsPhrase(stems, pattern) {
+				hits++
+			}
+		}
+		if hits > most {
+			best, most = &faqs[i], hits
+		}
+	}
+	return best
+}
+
+func parseFaq(patterns string, answer string) (*entity.Faq, error) {
+	faq := &entity.Faq{
+		Patterns: SplitFaqPatterns(patterns),
+		Answer:   strings.TrimSpace(answer),
+	}
+	if len(faq.Patterns) == 0 || faq.Answer == "" {
+		return nil, customErr.ErrInvalidRequest
+	}
+
+	return faq, nil
+}
+
+// SplitFaqPatterns - признаки вопроса, перечисленные через ";"
+func SplitFaqPatterns(input string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(input, ";") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func faqTarget(id int) string {
+	return fmt.Sprintf("faq %d", id)
+}
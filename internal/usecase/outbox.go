@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	outboxBatchSize = 50
+	// outboxLease - через сколько сообщение, отправка которого не была отмечена, уйдет повторно
+	outboxLease        = 5 * time.Minute
+	outboxRetryBackoff = 30 * time.Second
+	outboxMaxBackoff   = time.Hour
+	// outboxKeepSent - сколько хранятся доставленные сообщения
+	outboxKeepSent  = 7 * 24 * time.Hour
+	outboxDeadLimit = 10
+)
+
+type OutboxService interface {
+	// Enqueue - сохраняет сообщение в транзакции из ctx, если она открыта. questionID > 0 привязывает
+	// доставленное сообщение к вопросу, ответ на него станет уточнением
+	Enqueue(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string, questionID int) error
+	// Notify - будит цикл доставки, вызывается после фиксации транзакции с Enqueue
+	Notify()
+	// Run - блокирующий цикл доставки с повторами. Исчерпавшие попытки сообщения получают статус dead
+	Run(ctx context.Context)
+
+	GetStats(ctx context.Context) (*entity.OutboxStats, error)
+	GetDead(ctx context.Context) ([]entity.OutboxMessage, error)
+	Retry(ctx context.Context, actorID int64, id int64) error
+	RetryAll(ctx context.Context, actorID int64) (int, error)
+	FormatDead(stats *entity.OutboxStats, messages []entity.OutboxMessage) string
+}
+
+type outboxService struct {
+	outboxRepo   repo.OutboxRepo
+	threadRepo   repo.ThreadRepo
+	auditService AuditService
+	log          *logger.Logger
+	tgMsg        customMsg.Message
+
+	interval    time.Duration
+	maxAttempts int
+	wake        chan struct{}
+}
+
+func NewOutboxService(
+	outboxRepo repo.OutboxRepo,
+	threadRepo repo.ThreadRepo,
+	auditService AuditService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+	interval time.Duration,
+	maxAttempts int,
+) (OutboxService, error) {
+	if outboxRepo == nil {
+		return nil, errors.New("outboxRepo is nil")
+	}
+	if threadRepo == nil {
+		return nil, errors.New("threadRepo is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &outboxService{
+		outboxRepo:   outboxRepo,
+		threadRepo:   threadRepo,
+		auditService: auditService,
+		log:          log,
+		tgMsg:        tgMsg,
+		interval:     interval,
+		maxAttempts:  max(maxAttempts, 1),
+		wake:         make(chan struct{}, 1),
+	}, nil
+}
+
+func (o *outboxService) Enqueue(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string, questionID int) error {
+	message := &entity.OutboxMessage{
+		ChatID:     chatID,
+		Text:       text,
+		QuestionID: questionID,
+	}
+	if markup != nil {
+		data, err := json.Marshal(markup)
+		if err != nil {
+			return err
+		}
+		message.Markup = data
+	}
+
+	if err := o.outboxRepo.Create(ctx, message); err != nil {
+		o.log.Error("outboxRepo.Create: failed to enqueue message to %d: %v", chatID, err)
+		return err
+	}
+	return nil
+}
+
+func (o *outboxService) Notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *outboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		o.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		case <-cleanup.C:
+			o.deleteSent(ctx)
+		}
+	}
+}
+
+func (o *outboxService) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := o.outboxRepo.ClaimDue(ctx, outboxBatchSize, outboxLease)
+		if err != nil {
+			o.log.Error("outboxRepo.ClaimDue: %v", err)
+			return
+		}
+
+		for _, message := range messages {
+			// сообщение уже отправлено, поэтому результат сохраняется и при остановке бота
+			o.send(context.WithoutCancel(ctx), message)
+		}
+
+		if len(messages) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (o *outboxService) send(ctx context.Context, message entity.OutboxMessage) {
+	var markup *tgbotapi.InlineKeyboardMarkup
+	if len(message.Markup) > 0 {
+		markup = new(tgbotapi.InlineKeyboardMarkup)
+		if err := json.Unmarshal(message.Markup, markup); err != nil {
+			o.markDead(ctx, message, err)
+			return
+		}
+	}
+
+	msgID, err := o.tgMsg.SendNewMessage(ctx, message.ChatID, markup, message.Text)
+	if err != nil {
+		if customMsg.IsPermanent(err) || message.Attempts >= o.maxAttempts {
+			o.markDead(ctx, message, err)
+			return
+		}
+
+		delay := backoff(message.Attempts)
+		if err := o.outboxRepo.Reschedule(ctx, message.ID, err.Error(), delay); err != nil {
+			o.log.Error("outboxRepo.Reschedule: failed to reschedule message %d: %v", message.ID, err)
+		}
+		o.log.Info("Outbox message %d to %d failed, retry in %s: %v", message.ID, message.ChatID, delay, err)
+		return
+	}
+
+	if err := o.outboxRepo.MarkSent(ctx, message.ID); err != nil {
+		o.log.Error("outboxRepo.MarkSent: failed to mark message %d: %v", message.ID, err)
+	}
+	if message.QuestionID > 0 {
+		if err := o.threadRepo.LinkMessage(ctx, message.ChatID, msgID, message.QuestionID); err != nil {
+			o.log.Error("threadRepo.LinkMessage: failed to link message %d to question %d: %v", msgID, message.QuestionID, err)
+		}
+	}
+}
+
+func (o *outboxService) markDead(ctx context.Context, message entity.OutboxMessage, cause error) {
+	if err := o.outboxRepo.MarkDead(ctx, message.ID, cause.Error()); err != nil {
+		o.log.Error("outboxRepo.MarkDead: failed to mark message %d: %v", message.ID, err)
+		return
+	}
+	o.log.Error("Outbox message %d to %d is dead after %d attempts: %v", message.ID, message.ChatID, message.Attempts, cause)
+}
+
+// backoff - задержка перед следующей попыткой растет вдвое после каждой неудачной
+func backoff(attempts int) time.Duration {
+	delay := outboxRetryBackoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxBackoff)
+}
+
+func (o *outboxService) deleteSent(ctx context.Context) {
+	deleted, err := o.outboxRepo.DeleteSent(ctx, outboxKeepSent)
+	if err != nil {
+		o.log.Error("outboxRepo.DeleteSent: %v", err)
+		return
+	}
+	if deleted > 0 {
+		o.log.Info("Deleted %d delivered outbox messages", deleted)
+	}
+}
+
+func (o *outboxService) GetStats(ctx context.Context) (*entity.OutboxStats, error) {
+	return o.outboxRepo.GetStats(ctx)
+}
+
+func (o *outboxService) GetDead(ctx context.Context) ([]entity.OutboxMessage, error) {
+	return o.outboxRepo.GetDead(ctx, outboxDeadLimit)
+}
+
+func (o *outboxService) Retry(ctx context.Context, actorID int64, id int64) error {
+	ok, err := o.outboxRepo.Retry(ctx, id)
+	if err != nil {
+		o.log.Error("outboxRepo.Retry: failed to retry message %d: %v", id, err)
+		return err
+	}
+	if !ok {
+		return customErr.ErrNotFound
+	}
+
+	o.auditService.Log(ctx, actorID, entity.AuditOutboxRetry, outboxTarget(id), nil)
+	o.Notify()
+
+	return nil
+}
+
+func (o *outboxService) RetryAll(ctx context.Context, actorID int64) (int, error) {
+	count, err := o.outboxRepo.RetryAllDead(ctx)
+	if err != nil {
+		o.log.Error("outboxRepo.RetryAllDead: %v", err)
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	o.auditService.Log(ctx, actorID, entity.AuditOutboxRetry, "outbox", entity.AuditDiff{
+		"count": {New: count},
+	})
+	o.Notify()
+
+	return count, nil
+}
+
+func (o *outboxService) FormatDead(stats *entity.OutboxStats, messages []entity.OutboxMessage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Доставка сообщений</b>\n\nВ очереди: %d\nНе доставлено: %d\n", stats.Pending, stats.Dead)
+
+	if len(messages) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\nПоследние недоставленные:\n")
+	for _, message := range messages {
+		fmt.Fprintf(&sb, "\n#%d → %d, попыток: %d\n<i>%s</i>\n%s\n",
+			message.ID, message.ChatID, message.Attempts,
+			html.EscapeString(shorten(message.LastError, 100)),
+			html.EscapeString(shorten(message.Text, 80)))
+	}
+	return sb.String()
+}
+
+func shorten(s string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func outboxTarget(id int64) string {
+	return fmt.Sprintf("outbox %d", id)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// fakeOutboxRepo - записывает, чем закончилась отправка. Остальные методы OutboxRepo не вызываются
+type fakeOutboxRepo struct {
+	repo.OutboxRepo
+
+	sent        []int64
+	dead        []int64
+	rescheduled map[int64]time.Duration
+}
+
+func (f *fakeOutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeOutboxRepo) Reschedule(ctx context.Context, id int64, lastError string, delay time.Duration) error {
+	f.rescheduled[id] = delay
+	return nil
+}
+
+func (f *fakeOutboxRepo) MarkDead(ctx context.Context, id int64, lastError string) error {
+	f.dead = append(f.dead, id)
+	return nil
+}
+
+// fakeSender - SendNewMessage возвращает err, остальные методы Message не вызываются
+type fakeSender struct {
+	customMsg.Message
+	err error
+}
+
+func (f *fakeSender) SendNewMessage(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	return 1, nil
+}
+
+func TestOutboxSend(t *testing.T) {
+	blocked := &tgbotapi.Error{Code: http.StatusForbidden, Message: "bot was blocked by the user"}
+	network := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		message  entity.OutboxMessage
+		err      error
+		wantSent bool
+		wantDead bool
+		// wantDelay - 0, если сообщение не откладывается
+		wantDelay time.Duration
+	}{
+		{name: "delivered", message: entity.OutboxMessage{ID: 1, Attempts: 1}, wantSent: true},
+		{name: "temporary error is retried", message: entity.OutboxMessage{ID: 1, Attempts: 1}, err: network,
+			wantDelay: 30 * time.Second},
+		{name: "retry delay grows with attempts", message: entity.OutboxMessage{ID: 1, Attempts: 3}, err: network,
+			wantDelay: 2 * time.Minute},
+		{name: "last attempt goes to dead", message: entity.OutboxMessage{ID: 1, Attempts: 5}, err: network, wantDead: true},
+		{name: "permanent error goes to dead at once", message: entity.OutboxMessage{ID: 1, Attempts: 1}, err: blocked,
+			wantDead: true},
+		{name: "broken markup goes to dead", message: entity.OutboxMessage{ID: 1, Attempts: 1, Markup: []byte(`{`)},
+			wantDead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxRepo := &fakeOutboxRepo{rescheduled: make(map[int64]time.Duration)}
+			o := &outboxService{
+				outboxRepo:  outboxRepo,
+				log:         logger.New(),
+				tgMsg:       &fakeSender{err: tt.err},
+				maxAttempts: 5,
+			}
+
+			o.send(context.Background(), tt.message)
+
+			if sent := len(outboxRepo.sent) == 1; sent != tt.wantSent {
+				t.Fatalf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if dead := len(outboxRepo.dead) == 1; dead != tt.wantDead {
+				t.Fatalf("dead = %v, want %v", dead, tt.wantDead)
+			}
+			if delay := outboxRepo.rescheduled[tt.message.ID]; delay != tt.wantDelay {
+				t.Fatalf("rescheduled in %s, want %s", delay, tt.wantDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+)
+
+type PermissionService interface {
+	GetUserPermissions(ctx context.Context, userID int64) (entity.PermissionSet, error)
+	HasPermission(ctx context.Context, userID int64, permission entity.Permission) (bool, error)
+}
+
+type permissionService struct {
+	permissionRepo repo.PermissionRepo
+	log            *logger.Logger
+}
+
+func NewPermissionService(permissionRepo repo.PermissionRepo, log *logger.Logger) (PermissionService, error) {
+	if permissionRepo == nil {
+		return nil, errors.New("permissionRepo is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &permissionService{
+		permissionRepo: permissionRepo,
+		log:            log,
+	}, nil
+}
+
+func (p *permissionService) GetUserPermissions(ctx context.Context, userID int64) (entity.PermissionSet, error) {
+	permissions, err := p.permissionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		p.log.Error("permissionRepo.GetByUserID: failed to get permissions of %d: %v", userID, err)
+		return nil, err
+	}
+
+	return entity.NewPermissionSet(permissions...), nil
+}
+
+func (p *permissionService) HasPermission(ctx context.Context, userID int64, permission entity.Permission) (bool, error) {
+	permissions, err := p.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Has(permission), nil
+}
@@ -0,0 +1,634 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strconv"
+	"time"
+)
+
+const (
+	questionListLimit = 20
+	SearchPageSize    = 5
+)
+
+type QuestionService interface {
+	CreateQuestion(ctx context.Context, userID int64, text string) error
+	// FollowUp - уточнение автора ответом на сообщение бота replyToID, false - сообщение не связано с вопросом
+	FollowUp(ctx context.Context, userID int64, replyToID int, text string) (bool, error)
+
+	GetQuestionByID(ctx context.Context, id int) (*entity.Question, error)
+	GetQuestionByNotifyMessage(ctx context.Context, chatID int64, messageID int) (*entity.Question, error)
+	GetQuestionsBetween(ctx context.Context, from, to time.Time) ([]entity.Question, error)
+	CountUnanswered(ctx context.Context) (int, error)
+
+	Check(ctx context.Context, actorID int64, id int) error
+	Reject(ctx context.Context, actorID int64, id int) error
+	Answer(ctx context.Context, actorID int64, id int, text string) error
+	BanAuthor(ctx context.Context, actorID int64, id int) error
+	Publish(ctx context.Context, actorID int64, id int) error
+
+	Claim(ctx context.Context, actorID int64, id int) error
+	Release(ctx context.Context, actorID int64, id int) error
+	Assign(ctx context.Context, actorID int64, id int, assigneeID int64) error
+	GetAssigned(ctx context.Context, assigneeID int64) ([]entity.Question, error)
+	// GetList - вопросы для списка в панели, tagID = 0 означает без фильтра по тегу
+	GetList(ctx context.Context, tagID int, sort entity.QuestionSort) ([]entity.Question, error)
+	// RunClaimRelease - блокирующий цикл, снимающий закрепления, которые простаивают дольше claimTTL
+	RunClaimRelease(ctx context.Context)
+
+	// GetSimilar - похожие основные вопросы, которые можно объединить с вопросом id
+	GetSimilar(ctx context.Context, id int) ([]entity.SimilarQuestion, error)
+	// Merge - делает dupID дубликатом кластера вопроса id, ответ на основной вопрос получат все авторы
+	Merge(ctx context.Context, actorID int64, id int, dupID int) error
+	// Detach - отделяет дубликат от кластера
+	Detach(ctx context.Context, actorID int64, id int) error
+
+	// Search - страница page результатов поиска и общее число страниц
+	Search(ctx context.Context, query string, page int) ([]entity.SearchResult, int, error)
+
+	// Card - текст и кнопки карточки вопроса
+	Card(ctx context.Context, id int) (string, tgbotapi.InlineKeyboardMarkup, error)
+}
+
+type questionService struct {
+	questionRepo  repo.QuestionRepo
+	userRepo      repo.UserRepo
+	tagRepo       repo.TagRepo
+	threadRepo    repo.ThreadRepo
+	transactor    repo.Transactor
+	tagRules      TagRuleService
+	auditService  AuditService
+	outboxService OutboxService
+	permissions   PermissionService
+	log           *logger.Logger
+	tgMsg         customMsg.Message
+
+	adminChatID int64
+	claimTTL    time.Duration
+
+	duplicateThreshold float64
+	duplicateWindow    time.Duration
+}
+
+func NewQuestionService(
+	questionRepo repo.QuestionRepo,
+	userRepo repo.UserRepo,
+	tagRepo repo.TagRepo,
+	threadRepo repo.ThreadRepo,
+	transactor repo.Transactor,
+	tagRules TagRuleService,
+	auditService AuditService,
+	outboxService OutboxService,
+	permissions PermissionService,
+	log *logger.Logger,
+	tgMsg customMsg.Message,
+	adminChatID int64,
+	claimTTL time.Duration,
+	duplicateThreshold float64,
+	duplicateWindow time.Duration,
+) (QuestionService, error) {
+	if questionRepo == nil {
+		return nil, errors.New("questionRepo is nil")
+	}
+	if userRepo == nil {
+		return nil, errors.New("userRepo is nil")
+	}
+	if tagRepo == nil {
+		return nil, errors.New("tagRepo is nil")
+	}
+	if threadRepo == nil {
+		return nil, errors.New("threadRepo is nil")
+	}
+	if transactor == nil {
+		return nil, errors.New("transactor is nil")
+	}
+	if tagRules == nil {
+		return nil, errors.New("tagRules is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if outboxService == nil {
+		return nil, errors.New("outboxService is nil")
+	}
+	if permissions == nil {
+		return nil, errors.New("permissions is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+	if tgMsg == nil {
+		return nil, errors.New("tgMsg is nil")
+	}
+
+	return &questionService{
+		questionRepo:  questionRepo,
+		userRepo:      userRepo,
+		tagRepo:       tagRepo,
+		threadRepo:    threadRepo,
+		transactor:    transactor,
+		tagRules:      tagRules,
+		auditService:  auditService,
+		outboxService: outboxService,
+		permissions:   permissions,
+		log:           log,
+		tgMsg:         tgMsg,
+		adminChatID:   adminChatID,
+		claimTTL:      claimTTL,
+
+		duplicateThreshold: duplicateThreshold,
+		duplicateWindow:    duplicateWindow,
+	}, nil
+}
+
+func (q *questionService) CreateQuestion(ctx context.Context, userID int64, text string) error {
+	user, err := q.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		q.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return err
+	}
+
+	if user.IsBanned {
+		q.log.Info("Skip question from banned user: %s", user.String())
+		return nil
+	}
+
+	question := &entity.Question{
+		UserID:    userID,
+		Question:  text,
+		Status:    entity.QuestionNew,
+		CreatedAt: time.Now().Local(),
+	}
+
+	// подтверждение уходит через outbox только вместе с сохраненным вопросом
+	err = q.transactor.WithTx(ctx, func(ctx context.Context) error {
+		question.ID, err = q.questionRepo.Create(ctx, question)
+		if err != nil {
+			q.log.Error("questionRepo.Create: failed to insert question: %v", err)
+			return err
+		}
+		return q.outboxService.Enqueue(ctx, userID, nil, "Я получил ваше сообщение и отправил его аналитикам", question.ID)
+	})
+	if err != nil {
+		return err
+	}
+	q.outboxService.Notify()
+
+	if _, err := q.tagRules.Classify(ctx, question.ID, question.Question); err != nil {
+		q.log.Error("tagRules.Classify: failed to tag question %d: %v", question.ID, err)
+	}
+
+	// дубликат открытого вопроса не создает отдельного уведомления, обновляется карточка основного
+	if root := q.duplicateRoot(ctx, question); root != nil {
+		q.refreshNotification(ctx, root)
+		return nil
+	}
+
+	q.notifyAdminChat(ctx, question)
+
+	return nil
+}
+
+func (q *questionService) GetQuestionByID(ctx context.Context, id int) (*entity.Question, error) {
+	return q.questionRepo.GetByID(ctx, id)
+}
+
+func (q *questionService) GetQuestionByNotifyMessage(ctx context.Context, chatID int64, messageID int) (*entity.Question, error) {
+	return q.questionRepo.GetByNotifyMessage(ctx, chatID, messageID)
+}
+
+func (q *questionService) GetQuestionsBetween(ctx context.Context, from, to time.Time) ([]entity.Question, error) {
+	return q.questionRepo.GetCreatedBetween(ctx, from, to)
+}
+
+func (q *questionService) CountUnanswered(ctx context.Context) (int, error) {
+	return q.questionRepo.CountByStatus(ctx, entity.QuestionNew, entity.QuestionChecked)
+}
+
+func (q *questionService) Check(ctx context.Context, actorID int64, id int) error {
+	return q.changeStatus(ctx, actorID, id, entity.QuestionChecked, entity.AuditQuestionCheck)
+}
+
+func (q *questionService) Reject(ctx context.Context, actorID int64, id int) error {
+	return q.changeStatus(ctx, actorID, id, entity.QuestionRejected, entity.AuditQuestionReject)
+}
+
+// Answer - ответ получают автор вопроса id и авторы всех открытых вопросов его кластера
+func (q *questionService) Answer(ctx context.Context, actorID int64, id int, text string) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.AssigneeID != 0 && question.AssigneeID != actorID {
+		return customErr.ErrAlreadyClaimed
+	}
+
+	if err := q.answerOne(ctx, actorID, question, text); err != nil {
+		return err
+	}
+
+	cluster, err := q.clusterOf(ctx, question)
+	if err != nil {
+		q.log.Error("failed to get cluster of question %d: %v", id, err)
+		return nil
+	}
+	for i := range cluster {
+		if cluster[i].ID != id && cluster[i].IsOpen() {
+			if err := q.answerOne(ctx, actorID, &cluster[i], text); err != nil {
+				q.log.Error("failed to answer duplicate %d of question %d: %v", cluster[i].ID, id, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (q *questionService) answerOne(ctx context.Context, actorID int64, question *entity.Question, text string) error {
+	id := question.ID
+
+	answerText := fmt.Sprintf("Ответ аналитиков на ваш вопрос:\n<i>«%s»</i>\n\n%s",
+		html.EscapeString(question.Question), html.EscapeString(text))
+	// ответ сохраняется вместе с сообщением автору, доставку с повторами берет на себя outbox
+	err := q.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if err := q.questionRepo.UpdateAnswer(ctx, id, text, actorID); err != nil {
+			q.log.Error("questionRepo.UpdateAnswer: failed to save answer of question %d: %v", id, err)
+			return err
+		}
+		if err := q.threadRepo.Create(ctx, &entity.ThreadMessage{
+			QuestionID: id,
+			Kind:       entity.ThreadAnswer,
+			AuthorID:   actorID,
+			Text:       text,
+		}); err != nil {
+			q.log.Error("threadRepo.Create: failed to save answer of question %d to thread: %v", id, err)
+			return err
+		}
+		return q.outboxService.Enqueue(ctx, question.UserID, nil, answerText, id)
+	})
+	if err != nil {
+		return err
+	}
+	q.outboxService.Notify()
+	q.firstAction(ctx, id, actorID)
+
+	q.auditService.Log(ctx, actorID, entity.AuditAnswer, questionTarget(id), entity.AuditDiff{
+		"status": {Old: question.Status, New: entity.QuestionAnswered},
+		"answer": {New: text},
+	})
+
+	question.Status = entity.QuestionAnswered
+	question.Answer = text
+	question.AnsweredBy = actorID
+	q.refreshNotification(ctx, question)
+
+	return nil
+}
+
+// Publish - ответ на вопрос опубликован в канале
+func (q *questionService) Publish(ctx context.Context, actorID int64, id int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.PublishedAt != nil {
+		return nil
+	}
+
+	publishedAt, err := q.questionRepo.UpdatePublished(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.UpdatePublished: failed to publish question %d: %v", id, err)
+		return err
+	}
+	question.PublishedAt = &publishedAt
+	q.firstAction(ctx, id, actorID)
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionPublish, questionTarget(id), nil)
+
+	q.refreshNotification(ctx, question)
+
+	return nil
+}
+
+func (q *questionService) BanAuthor(ctx context.Context, actorID int64, id int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	author, err := q.userRepo.GetUserByID(ctx, question.UserID)
+	if err != nil {
+		q.log.Error("userRepo.GetUserByID: failed to get user %d: %v", question.UserID, err)
+		return err
+	}
+
+	if err := q.userRepo.UpdateBanned(ctx, author.ID, true); err != nil {
+		q.log.Error("userRepo.UpdateBanned: failed to ban user %d: %v", author.ID, err)
+		return err
+	}
+
+	q.auditService.Log(ctx, actorID, entity.AuditUserBan, userTarget(author), entity.AuditDiff{
+		"is_banned": {Old: author.IsBanned, New: true},
+	})
+
+	if question.Status == entity.QuestionAnswered || question.Status == entity.QuestionRejected {
+		q.refreshNotification(ctx, question)
+		return nil
+	}
+
+	return q.changeStatus(ctx, actorID, id, entity.QuestionRejected, entity.AuditQuestionReject)
+}
+
+func (q *questionService) changeStatus(ctx context.Context, actorID int64, id int, status entity.QuestionStatus, action entity.AuditAction) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.Status == status {
+		return nil
+	}
+	if !question.IsOpen() {
+		q.refreshNotification(ctx, question)
+		return customErr.ErrQuestionClosed
+	}
+
+	ok, err := q.questionRepo.UpdateOpenStatus(ctx, id, status)
+	if err != nil {
+		q.log.Error("questionRepo.UpdateOpenStatus: failed to update question %d: %v", id, err)
+		return err
+	}
+	if !ok {
+		// вопрос закрыли между чтением и обновлением
+		if question, err = q.questionRepo.GetByID(ctx, id); err == nil {
+			q.refreshNotification(ctx, question)
+		}
+		return customErr.ErrQuestionClosed
+	}
+	q.firstAction(ctx, id, actorID)
+
+	q.auditService.Log(ctx, actorID, action, questionTarget(id), entity.AuditDiff{
+		"status": {Old: question.Status, New: status},
+	})
+
+	question.Status = status
+	q.refreshNotification(ctx, question)
+
+	return nil
+}
+
+func (q *questionService) Claim(ctx context.Context, actorID int64, id int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.AssigneeID == actorID {
+		return nil
+	}
+
+	ok, err := q.questionRepo.Claim(ctx, id, actorID)
+	if err != nil {
+		q.log.Error("questionRepo.Claim: failed to claim question %d: %v", id, err)
+		return err
+	}
+	if !ok {
+		return customErr.ErrAlreadyClaimed
+	}
+	q.firstAction(ctx, id, actorID)
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionAssign, questionTarget(id), entity.AuditDiff{
+		"assignee_id": {Old: question.AssigneeID, New: actorID},
+	})
+
+	question.AssigneeID = actorID
+	q.refreshNotification(ctx, question)
+
+	return nil
+}
+
+func (q *questionService) Release(ctx context.Context, actorID int64, id int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.AssigneeID == 0 {
+		return nil
+	}
+	if question.AssigneeID != actorID {
+		return customErr.ErrAlreadyClaimed
+	}
+
+	if err := q.questionRepo.Release(ctx, id); err != nil {
+		q.log.Error("questionRepo.Release: failed to release question %d: %v", id, err)
+		return err
+	}
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionAssign, questionTarget(id), entity.AuditDiff{
+		"assignee_id": {Old: question.AssigneeID, New: nil},
+	})
+
+	question.AssigneeID = 0
+	q.refreshNotification(ctx, question)
+
+	return nil
+}
+
+// Assign - передать вопрос может тот, за кем он закреплен, любой отвечающий, если вопрос свободен,
+// и обладатель role.manage. Получатель должен иметь право отвечать на вопросы
+func (q *questionService) Assign(ctx context.Context, actorID int64, id int, assigneeID int64) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.AssigneeID == assigneeID {
+		return nil
+	}
+
+	exists, err := q.userRepo.IsUserExistByUserID(ctx, assigneeID)
+	if err != nil {
+		q.log.Error("userRepo.IsUserExistByUserID: failed to check user %d: %v", assigneeID, err)
+		return err
+	}
+	if !exists {
+		return customErr.ErrNotFound
+	}
+
+	canAnswer, err := q.permissions.HasPermission(ctx, assigneeID, entity.PermQuestionAnswer)
+	if err != nil {
+		return err
+	}
+	if !canAnswer {
+		return customErr.ErrAssigneeNotAllowed
+	}
+
+	override, err := q.permissions.HasPermission(ctx, actorID, entity.PermRoleManage)
+	if err != nil {
+		return err
+	}
+
+	ok, err := q.questionRepo.Assign(ctx, id, assigneeID, actorID, override)
+	if err != nil {
+		q.log.Error("questionRepo.Assign: failed to assign question %d: %v", id, err)
+		return err
+	}
+	if !ok {
+		return customErr.ErrAlreadyClaimed
+	}
+	q.firstAction(ctx, id, actorID)
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionAssign, questionTarget(id), entity.AuditDiff{
+		"assignee_id": {Old: question.AssigneeID, New: assigneeID},
+	})
+
+	question.AssigneeID = assigneeID
+	q.refreshNotification(ctx, question)
+
+	if assigneeID != actorID {
+		q.notifyAssignee(ctx, question, actorID)
+	}
+
+	return nil
+}
+
+func (q *questionService) GetAssigned(ctx context.Context, assigneeID int64) ([]entity.Question, error) {
+	return q.questionRepo.GetOpenByAssignee(ctx, assigneeID)
+}
+
+func (q *questionService) GetList(ctx context.Context, tagID int, sort entity.QuestionSort) ([]entity.Question, error) {
+	return q.questionRepo.GetList(ctx, tagID, sort, questionListLimit)
+}
+
+func (q *questionService) Search(ctx context.Context, query string, page int) ([]entity.SearchResult, int, error) {
+	results, total, err := q.questionRepo.Search(ctx, query, page*SearchPageSize, SearchPageSize)
+	if err != nil {
+		q.log.Error("questionRepo.Search: failed to search %q: %v", query, err)
+		return nil, 0, err
+	}
+
+	return results, (total + SearchPageSize - 1) / SearchPageSize, nil
+}
+
+func (q *questionService) RunClaimRelease(ctx context.Context) {
+	if q.claimTTL <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		released, err := q.questionRepo.ReleaseIdle(ctx, q.claimTTL)
+		if err != nil {
+			q.log.Error("questionRepo.ReleaseIdle: %v", err)
+			continue
+		}
+
+		for i := range released {
+			q.log.Info("Question claim released by timeout: %s", released[i].String())
+			q.refreshNotification(ctx, &released[i])
+		}
+	}
+}
+
+// firstAction - время первой реакции нужно только для статистики, поэтому ошибка не прерывает действие
+func (q *questionService) firstAction(ctx context.Context, id int, actorID int64) {
+	if err := q.questionRepo.UpdateFirstAction(ctx, id, actorID); err != nil {
+		q.log.Error("questionRepo.UpdateFirstAction: failed to save first action of question %d: %v", id, err)
+	}
+}
+
+func (q *questionService) notifyAssignee(ctx context.Context, question *entity.Question, actorID int64) {
+	text, cardMarkup, err := q.Card(ctx, question.ID)
+	if err != nil {
+		q.log.Error("failed to build card of question %d: %v", question.ID, err)
+		return
+	}
+
+	from := strconv.FormatInt(actorID, 10)
+	if actor, err := q.userRepo.GetUserByID(ctx, actorID); err == nil {
+		from = userTarget(actor)
+	}
+
+	text = fmt.Sprintf("Вам передан вопрос от %s\n\n%s", html.EscapeString(from), text)
+	if err := q.outboxService.Enqueue(ctx, question.AssigneeID, &cardMarkup, text, 0); err != nil {
+		q.log.Error("failed to notify assignee %d about question %d: %v", question.AssigneeID, question.ID, err)
+		return
+	}
+	q.outboxService.Notify()
+}
+
+func (q *questionService) notifyAdminChat(ctx context.Context, question *entity.Question) {
+	if q.adminChatID == 0 {
+		return
+	}
+
+	text, cardMarkup, err := q.Card(ctx, question.ID)
+	if err != nil {
+		q.log.Error("failed to build card of question %d: %v", question.ID, err)
+		return
+	}
+
+	msgID, err := q.tgMsg.SendNewMessage(ctx, q.adminChatID, &cardMarkup, text)
+	if err != nil {
+		q.log.Error("failed to notify admin chat about question %d: %v", question.ID, err)
+		return
+	}
+
+	if err := q.questionRepo.UpdateNotifyMessage(ctx, question.ID, q.adminChatID, msgID); err != nil {
+		q.log.Error("questionRepo.UpdateNotifyMessage: failed to save notification of question %d: %v", question.ID, err)
+	}
+}
+
+// refreshNotification - обновляет карточку вопроса в чате администраторов
+func (q *questionService) refreshNotification(ctx context.Context, question *entity.Question) {
+	if question.NotifyChatID == 0 || question.NotifyMessageID == 0 {
+		return
+	}
+
+	text, cardMarkup, err := q.Card(ctx, question.ID)
+	if err != nil {
+		q.log.Error("failed to build card of question %d: %v", question.ID, err)
+		return
+	}
+
+	if _, err := q.tgMsg.SendEditMessage(ctx, question.NotifyChatID, question.NotifyMessageID, &cardMarkup, text); err != nil {
+		q.log.Error("failed to refresh notification of question %d: %v", question.ID, err)
+	}
+}
+
+func questionTarget(id int) string {
+	return "question #" + strconv.Itoa(id)
+}
+
+func userTarget(user *entity.User) string {
+	if user.TGUsername != "" {
+		return fmt.Sprintf("@%s (%d)", user.TGUsername, user.ID)
+	}
+	return strconv.FormatInt(user.ID, 10)
+}
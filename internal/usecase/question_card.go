@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+	"strings"
+	"time"
+)
+
+func (q *questionService) Card(ctx context.Context, id int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	author, err := q.userRepo.GetUserByID(ctx, question.UserID)
+	if err != nil {
+		q.log.Error("userRepo.GetUserByID: failed to get user %d: %v", question.UserID, err)
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<b>Вопрос #%d</b>\nОт: %s\n", question.ID, html.EscapeString(userTarget(author))))
+	if author.ChannelFrom != "" {
+		sb.WriteString(fmt.Sprintf("Источник: %s\n", html.EscapeString(author.ChannelFrom)))
+	}
+	if author.IsBanned {
+		sb.WriteString("Пользователь заблокирован\n")
+	}
+	sb.WriteString(fmt.Sprintf("Дата: %s\n\n%s\n\nСтатус: %s",
+		question.CreatedAt.Format("02.01.2006 15:04"),
+		html.EscapeString(question.Question),
+		question.Status.Title()))
+
+	for _, event := range []struct {
+		title string
+		at    *time.Time
+	}{
+		{"Проверен", question.CheckedAt},
+		{"Отвечен", question.AnsweredAt},
+		{"Опубликован", question.PublishedAt},
+	} {
+		if event.at != nil {
+			sb.WriteString(fmt.Sprintf("\n%s: %s", event.title, event.at.Format("02.01.2006 15:04")))
+		}
+	}
+
+	tags, err := q.tagRepo.GetAssignments(ctx, question.ID)
+	if err != nil {
+		q.log.Error("tagRepo.GetAssignments: failed to get tags of question %d: %v", question.ID, err)
+	}
+	if len(tags) > 0 {
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			name := "#" + tag.Name
+			if tag.IsAuto() {
+				name += fmt.Sprintf(" (авто, %.0f%%)", tag.Confidence*100)
+			}
+			names = append(names, name)
+		}
+		sb.WriteString("\nТеги: " + html.EscapeString(strings.Join(names, ", ")))
+	}
+
+	if question.Votes > 0 {
+		sb.WriteString(fmt.Sprintf("\nГолосов: %d", question.Votes))
+	}
+	if question.ClusterID != 0 {
+		sb.WriteString(fmt.Sprintf("\nДубликат вопроса #%d", question.ClusterID))
+	}
+	if question.Duplicates > 0 {
+		sb.WriteString(q.clusterAuthors(ctx, question))
+	}
+
+	if question.AssigneeID != 0 && question.IsOpen() {
+		sb.WriteString("\nВ работе: " + html.EscapeString(q.userTitle(ctx, question.AssigneeID)))
+	}
+	thread, err := q.threadRepo.GetByQuestion(ctx, question.ID)
+	if err != nil {
+		q.log.Error("threadRepo.GetByQuestion: failed to get thread of question %d: %v", question.ID, err)
+	}
+	if hasFollowUps(thread) {
+		sb.WriteString("\n\n<b>Переписка:</b>")
+		for _, message := range thread {
+			author := "Автор"
+			if message.Kind == entity.ThreadAnswer {
+				author = "Ответ, " + q.userTitle(ctx, message.AuthorID)
+			}
+			sb.WriteString(fmt.Sprintf("\n%s %s:\n%s",
+				message.CreatedAt.Format("02.01 15:04"),
+				html.EscapeString(author),
+				html.EscapeString(message.Text)))
+		}
+	} else if question.Answer != "" {
+		sb.WriteString(fmt.Sprintf("\n\nОтвет (%s):\n%s",
+			html.EscapeString(q.userTitle(ctx, question.AnsweredBy)),
+			html.EscapeString(question.Answer)))
+	}
+
+	return sb.String(), markup.QuestionCard(markup.QuestionCardState{
+		ID:        question.ID,
+		Status:    string(question.Status),
+		Assigned:  question.AssigneeID != 0,
+		Published: question.PublishedAt != nil,
+		ClusterID: question.ClusterID,
+	}), nil
+}
+
+func hasFollowUps(thread []entity.ThreadMessage) bool {
+	for _, message := range thread {
+		if message.Kind == entity.ThreadFollowUp {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterAuthorsLimit - сколько авторов дубликатов перечисляется в карточке
+const clusterAuthorsLimit = 10
+
+func (q *questionService) clusterAuthors(ctx context.Context, root *entity.Question) string {
+	members, err := q.questionRepo.GetClusterMembers(ctx, root.ID)
+	if err != nil {
+		q.log.Error("questionRepo.GetClusterMembers: failed to get cluster %d: %v", root.ID, err)
+		return ""
+	}
+
+	total := len(members) + 1
+	authors := []string{html.EscapeString(q.userTitle(ctx, root.UserID))}
+	for _, member := range members {
+		if len(authors) == clusterAuthorsLimit {
+			break
+		}
+		authors = append(authors, html.EscapeString(q.userTitle(ctx, member.UserID)))
+	}
+	if total > len(authors) {
+		authors = append(authors, fmt.Sprintf("и еще %d", total-len(authors)))
+	}
+
+	return fmt.Sprintf("\n<b>Задан %d %s</b>: %s", total, timesWord(total), strings.Join(authors, ", "))
+}
+
+// timesWord - "раз" или "раза" после числа
+func timesWord(n int) string {
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+		return "раза"
+	}
+	return "раз"
+}
+
+func (q *questionService) userTitle(ctx context.Context, userID int64) string {
+	user, err := q.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		q.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return fmt.Sprint(userID)
+	}
+	return userTarget(user)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"time"
+)
+
+const (
+	// similarSuggestThreshold - порог pg_trgm по умолчанию, ниже него тексты почти не похожи
+	similarSuggestThreshold = 0.3
+	similarSuggestLimit     = 10
+)
+
+func (q *questionService) GetSimilar(ctx context.Context, id int) ([]entity.SimilarQuestion, error) {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return nil, err
+	}
+
+	return q.questionRepo.FindSimilar(ctx, question.Question, []int{question.ID, question.RootID()},
+		time.Now().Local().Add(-q.duplicateWindow), similarSuggestThreshold, false, similarSuggestLimit)
+}
+
+func (q *questionService) Merge(ctx context.Context, actorID int64, id int, dupID int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	dup, err := q.questionRepo.GetByID(ctx, dupID)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", dupID, err)
+		return err
+	}
+
+	rootID := question.RootID()
+	if dup.RootID() == rootID {
+		return nil
+	}
+
+	if err := q.questionRepo.SetCluster(ctx, dupID, rootID); err != nil {
+		q.log.Error("questionRepo.SetCluster: failed to merge question %d into %d: %v", dupID, rootID, err)
+		return err
+	}
+	q.firstAction(ctx, rootID, actorID)
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionMerge, questionTarget(dupID), entity.AuditDiff{
+		"cluster_id": {Old: dup.ClusterID, New: rootID},
+	})
+
+	root, err := q.questionRepo.GetByID(ctx, rootID)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", rootID, err)
+		return err
+	}
+
+	// кластер уже отвечен - присоединенные вопросы получают тот же ответ сразу
+	if root.Status == entity.QuestionAnswered && root.Answer != "" {
+		members, err := q.questionRepo.GetClusterMembers(ctx, rootID)
+		if err != nil {
+			q.log.Error("questionRepo.GetClusterMembers: failed to get cluster %d: %v", rootID, err)
+		}
+		for i := range members {
+			if members[i].IsOpen() {
+				if err := q.answerOne(ctx, actorID, &members[i], root.Answer); err != nil {
+					q.log.Error("failed to answer merged question %d: %v", members[i].ID, err)
+				}
+			}
+		}
+	}
+
+	q.refreshNotification(ctx, root)
+	q.refreshNotification(ctx, dup)
+	if question.ID != rootID {
+		q.refreshNotification(ctx, question)
+	}
+
+	return nil
+}
+
+func (q *questionService) Detach(ctx context.Context, actorID int64, id int) error {
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return err
+	}
+
+	if question.ClusterID == 0 {
+		return nil
+	}
+
+	if err := q.questionRepo.SetCluster(ctx, id, 0); err != nil {
+		q.log.Error("questionRepo.SetCluster: failed to detach question %d: %v", id, err)
+		return err
+	}
+
+	q.auditService.Log(ctx, actorID, entity.AuditQuestionMerge, questionTarget(id), entity.AuditDiff{
+		"cluster_id": {Old: question.ClusterID, New: nil},
+	})
+
+	if root, err := q.questionRepo.GetByID(ctx, question.ClusterID); err == nil {
+		q.refreshNotification(ctx, root)
+	}
+
+	// автоматически присоединенный дубликат не отправлялся в чат администраторов
+	question.ClusterID = 0
+	if question.NotifyMessageID == 0 {
+		q.notifyAdminChat(ctx, question)
+	} else {
+		q.refreshNotification(ctx, question)
+	}
+
+	return nil
+}
+
+// duplicateRoot - присоединяет новый вопрос к самому похожему открытому основному вопросу.
+// Возвращает основной вопрос или nil, если дубликат не найден
+func (q *questionService) duplicateRoot(ctx context.Context, question *entity.Question) *entity.Question {
+	if q.duplicateThreshold <= 0 {
+		return nil
+	}
+
+	similar, err := q.questionRepo.FindSimilar(ctx, question.Question, []int{question.ID},
+		time.Now().Local().Add(-q.duplicateWindow), q.duplicateThreshold, true, 1)
+	if err != nil {
+		q.log.Error("questionRepo.FindSimilar: failed to find duplicates of question %d: %v", question.ID, err)
+		return nil
+	}
+	if len(similar) == 0 {
+		return nil
+	}
+
+	root := similar[0].Question
+	if err := q.questionRepo.SetCluster(ctx, question.ID, root.ID); err != nil {
+		q.log.Error("questionRepo.SetCluster: failed to merge question %d into %d: %v", question.ID, root.ID, err)
+		return nil
+	}
+
+	q.log.Info("Question %d merged into %d, similarity %.2f", question.ID, root.ID, similar[0].Similarity)
+	question.ClusterID = root.ID
+
+	return &root
+}
+
+// clusterOf - основной вопрос кластера и все его дубликаты
+func (q *questionService) clusterOf(ctx context.Context, question *entity.Question) ([]entity.Question, error) {
+	root := question
+	if question.ClusterID != 0 {
+		var err error
+		root, err = q.questionRepo.GetByID(ctx, question.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	members, err := q.questionRepo.GetClusterMembers(ctx, root.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]entity.Question{*root}, members...), nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+)
+
+func (q *questionService) FollowUp(ctx context.Context, userID int64, replyToID int, text string) (bool, error) {
+	id, err := q.threadRepo.GetLinkedQuestion(ctx, userID, replyToID)
+	if err != nil {
+		if errors.Is(err, customErr.ErrNoRows) {
+			return false, nil
+		}
+		q.log.Error("threadRepo.GetLinkedQuestion: failed to get question of message %d: %v", replyToID, err)
+		return false, err
+	}
+
+	question, err := q.questionRepo.GetByID(ctx, id)
+	if err != nil {
+		q.log.Error("questionRepo.GetByID: failed to get question %d: %v", id, err)
+		return false, err
+	}
+	if question.UserID != userID {
+		return false, nil
+	}
+
+	user, err := q.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		q.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return false, err
+	}
+	if user.IsBanned {
+		q.log.Info("Skip follow-up from banned user: %s", user.String())
+		return true, nil
+	}
+
+	err = q.transactor.WithTx(ctx, func(ctx context.Context) error {
+		if err := q.threadRepo.Create(ctx, &entity.ThreadMessage{
+			QuestionID: id,
+			Kind:       entity.ThreadFollowUp,
+			AuthorID:   userID,
+			Text:       text,
+		}); err != nil {
+			q.log.Error("threadRepo.Create: failed to save follow-up of question %d: %v", id, err)
+			return err
+		}
+
+		// уточнение к отвеченному вопросу снова ставит его в очередь аналитиков
+		if question.Status == entity.QuestionAnswered {
+			if err := q.questionRepo.UpdateStatus(ctx, id, entity.QuestionChecked); err != nil {
+				q.log.Error("questionRepo.UpdateStatus: failed to reopen question %d: %v", id, err)
+				return err
+			}
+		}
+
+		return q.outboxService.Enqueue(ctx, userID, nil, "Я добавил уточнение к вашему вопросу и передал его аналитикам", id)
+	})
+	if err != nil {
+		return false, err
+	}
+	q.outboxService.Notify()
+
+	if question.Status == entity.QuestionAnswered {
+		q.log.Info("Question %d reopened by follow-up of user %d", id, userID)
+	}
+
+	// старая карточка обновляется, новая поднимает вопрос вниз чата администраторов
+	q.refreshNotification(ctx, question)
+	q.notifyAdminChat(ctx, question)
+
+	return true, nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"html"
+)
+
+type RelayService interface {
+	// Open - создает тему переписки с userID в группе поддержки или открывает закрытую
+	Open(ctx context.Context, actorID int64, userID int64) (*entity.RelayTopic, error)
+	Close(ctx context.Context, actorID int64, userID int64) error
+	// TopicOf - тема переписки, в которой написано сообщение группы поддержки
+	TopicOf(ctx context.Context, message *tgbotapi.Message) (*entity.RelayTopic, error)
+
+	// FromUser - копирует сообщение пользователя в его тему, false - открытой переписки нет
+	FromUser(ctx context.Context, message *tgbotapi.Message) (bool, error)
+	// FromAdmin - копирует сообщение из темы пользователю, false - сообщение не относится к переписке
+	FromAdmin(ctx context.Context, message *tgbotapi.Message) (bool, error)
+}
+
+type relayService struct {
+	relayRepo         repo.RelayRepo
+	userRepo          repo.UserRepo
+	permissionService PermissionService
+	auditService      AuditService
+	outboxService     OutboxService
+	log               *logger.Logger
+	forum             customMsg.Forum
+
+	// relayChatID - форум-супергруппа поддержки, 0 отключает переписку через темы
+	relayChatID int64
+}
+
+func NewRelayService(
+	relayRepo repo.RelayRepo,
+	userRepo repo.UserRepo,
+	permissionService PermissionService,
+	auditService AuditService,
+	outboxService OutboxService,
+	log *logger.Logger,
+	forum customMsg.Forum,
+	relayChatID int64,
+) (RelayService, error) {
+	if relayRepo == nil {
+		return nil, errors.New("relayRepo is nil")
+	}
+	if userRepo == nil {
+		return nil, errors.New("userRepo is nil")
+	}
+	if permissionService == nil {
+		return nil, errors.New("permissionService is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if outboxService == nil {
+		return nil, errors.New("outboxService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+	if forum == nil {
+		return nil, errors.New("forum is nil")
+	}
+
+	return &relayService{
+		relayRepo:         relayRepo,
+		userRepo:          userRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		outboxService:     outboxService,
+		log:               log,
+		forum:             forum,
+		relayChatID:       relayChatID,
+	}, nil
+}
+
+func (r *relayService) Open(ctx context.Context, actorID int64, userID int64) (*entity.RelayTopic, error) {
+	if r.relayChatID == 0 {
+		return nil, customErr.ErrRelayDisabled
+	}
+
+	topic, err := r.relayRepo.GetByUser(ctx, r.relayChatID, userID)
+	switch {
+	case errors.Is(err, customErr.ErrNoRows):
+		if topic, err = r.createTopic(ctx, userID); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		r.log.Error("relayRepo.GetByUser: failed to get topic of user %d: %v", userID, err)
+		return nil, err
+	case topic.IsOpen:
+		return topic, nil
+	default:
+		if err := r.forum.ReopenForumTopic(ctx, topic.ChatID, topic.ThreadID); err != nil {
+			r.log.Error("failed to reopen topic of user %d: %v", userID, err)
+		}
+		if err := r.relayRepo.SetOpen(ctx, topic.ChatID, userID, true); err != nil {
+			r.log.Error("relayRepo.SetOpen: failed to reopen topic of user %d: %v", userID, err)
+			return nil, err
+		}
+		topic.IsOpen = true
+
+		if _, err := r.forum.SendTopicMessage(ctx, topic.ChatID, topic.ThreadID, "Диалог снова открыт"); err != nil {
+			r.log.Error("failed to send message to topic of user %d: %v", userID, err)
+		}
+	}
+
+	r.notifyUser(ctx, userID, "С вами на связи аналитики. Пока диалог открыт, ваши сообщения в этом чате получают они напрямую")
+
+	r.auditService.Log(ctx, actorID, entity.AuditRelayOpen, relayTarget(userID), entity.AuditDiff{
+		"thread_id": {New: topic.ThreadID},
+	})
+
+	return topic, nil
+}
+
+func (r *relayService) Close(ctx context.Context, actorID int64, userID int64) error {
+	if r.relayChatID == 0 {
+		return customErr.ErrRelayDisabled
+	}
+
+	topic, err := r.relayRepo.GetByUser(ctx, r.relayChatID, userID)
+	if err != nil {
+		r.log.Error("relayRepo.GetByUser: failed to get topic of user %d: %v", userID, err)
+		return err
+	}
+	if !topic.IsOpen {
+		return nil
+	}
+
+	if err := r.relayRepo.SetOpen(ctx, topic.ChatID, userID, false); err != nil {
+		r.log.Error("relayRepo.SetOpen: failed to close topic of user %d: %v", userID, err)
+		return err
+	}
+
+	if _, err := r.forum.SendTopicMessage(ctx, topic.ChatID, topic.ThreadID,
+		"Диалог закрыт. Чтобы продолжить переписку, отправьте /reopen"); err != nil {
+		r.log.Error("failed to send message to topic of user %d: %v", userID, err)
+	}
+	if err := r.forum.CloseForumTopic(ctx, topic.ChatID, topic.ThreadID); err != nil {
+		r.log.Error("failed to close topic of user %d: %v", userID, err)
+	}
+
+	r.notifyUser(ctx, userID, "Диалог с аналитиками завершен. Новые сообщения снова будут приниматься как вопросы")
+
+	r.auditService.Log(ctx, actorID, entity.AuditRelayClose, relayTarget(userID), nil)
+
+	return nil
+}
+
+// notifyUser - уведомление уходит через outbox и будет доставлено, даже если Telegram сейчас недоступен
+func (r *relayService) notifyUser(ctx context.Context, userID int64, text string) {
+	if err := r.outboxService.Enqueue(ctx, userID, nil, text, 0); err != nil {
+		return
+	}
+	r.outboxService.Notify()
+}
+
+// TopicOf - в теме форума каждое сообщение отвечает либо на первое сообщение темы,
+// id которого совпадает с id темы, либо на другое сообщение переписки
+func (r *relayService) TopicOf(ctx context.Context, message *tgbotapi.Message) (*entity.RelayTopic, error) {
+	if r.relayChatID == 0 || message.Chat.ID != r.relayChatID || message.ReplyToMessage == nil {
+		return nil, customErr.ErrNotFound
+	}
+	replyToID := message.ReplyToMessage.MessageID
+
+	topic, err := r.relayRepo.GetByThread(ctx, r.relayChatID, replyToID)
+	if err == nil || !errors.Is(err, customErr.ErrNoRows) {
+		return topic, err
+	}
+
+	userID, err := r.relayRepo.GetMessageUser(ctx, r.relayChatID, replyToID)
+	if err != nil {
+		if errors.Is(err, customErr.ErrNoRows) {
+			return nil, customErr.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return r.relayRepo.GetByUser(ctx, r.relayChatID, userID)
+}
+
+func (r *relayService) FromUser(ctx context.Context, message *tgbotapi.Message) (bool, error) {
+	if r.relayChatID == 0 {
+		return false, nil
+	}
+
+	topic, err := r.relayRepo.GetByUser(ctx, r.relayChatID, message.From.ID)
+	if err != nil {
+		if errors.Is(err, customErr.ErrNoRows) {
+			return false, nil
+		}
+		r.log.Error("relayRepo.GetByUser: failed to get topic of user %d: %v", message.From.ID, err)
+		return false, err
+	}
+	if !topic.IsOpen {
+		return false, nil
+	}
+
+	copyID, err := r.forum.CopyMessage(ctx, topic.ChatID, topic.ThreadID, message.Chat.ID, message.MessageID)
+	if err != nil {
+		r.log.Error("failed to copy message of user %d to topic: %v", message.From.ID, err)
+		return true, err
+	}
+
+	if err := r.relayRepo.SaveMessage(ctx, topic.ChatID, copyID, topic.UserID); err != nil {
+		r.log.Error("relayRepo.SaveMessage: %v", err)
+	}
+
+	return true, nil
+}
+
+func (r *relayService) FromAdmin(ctx context.Context, message *tgbotapi.Message) (bool, error) {
+	if message.From == nil || message.From.IsBot {
+		return false, nil
+	}
+
+	topic, err := r.TopicOf(ctx, message)
+	if err != nil {
+		if errors.Is(err, customErr.ErrNotFound) {
+			return false, nil
+		}
+		r.log.Error("failed to get topic of message %d: %v", message.MessageID, err)
+		return false, err
+	}
+
+	permissions, err := r.permissionService.GetUserPermissions(ctx, message.From.ID)
+	if err != nil {
+		return true, err
+	}
+	if !permissions.Has(entity.PermQuestionAnswer) {
+		r.log.Info("Skip relay message of user %d without %s", message.From.ID, entity.PermQuestionAnswer)
+		return true, nil
+	}
+
+	if !topic.IsOpen {
+		if _, err := r.forum.SendTopicMessage(ctx, topic.ChatID, topic.ThreadID,
+			"Диалог закрыт, сообщение не отправлено. Чтобы продолжить переписку, отправьте /reopen"); err != nil {
+			r.log.Error("failed to send message to topic of user %d: %v", topic.UserID, err)
+		}
+		return true, nil
+	}
+
+	if _, err := r.forum.CopyMessage(ctx, topic.UserID, 0, message.Chat.ID, message.MessageID); err != nil {
+		r.log.Error("failed to copy relay message to user %d: %v", topic.UserID, err)
+		return true, err
+	}
+
+	// ответ на сообщение администратора тоже должен находить переписку
+	if err := r.relayRepo.SaveMessage(ctx, topic.ChatID, message.MessageID, topic.UserID); err != nil {
+		r.log.Error("relayRepo.SaveMessage: %v", err)
+	}
+
+	return true, nil
+}
+
+func (r *relayService) createTopic(ctx context.Context, userID int64) (*entity.RelayTopic, error) {
+	user, err := r.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		r.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return nil, err
+	}
+
+	threadID, err := r.forum.CreateForumTopic(ctx, r.relayChatID, userTarget(user))
+	if err != nil {
+		return nil, customErr.ErrServerError
+	}
+
+	topic := &entity.RelayTopic{
+		UserID:   userID,
+		ChatID:   r.relayChatID,
+		ThreadID: threadID,
+		IsOpen:   true,
+	}
+	if err := r.relayRepo.Create(ctx, topic); err != nil {
+		r.log.Error("relayRepo.Create: failed to save topic of user %d: %v", userID, err)
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Переписка с %s. Сообщения в этой теме получает пользователь.\n/close - закрыть диалог",
+		html.EscapeString(userTarget(user)))
+	if _, err := r.forum.SendTopicMessage(ctx, topic.ChatID, topic.ThreadID, text); err != nil {
+		r.log.Error("failed to send message to topic of user %d: %v", userID, err)
+	}
+
+	return topic, nil
+}
+
+func relayTarget(userID int64) string {
+	return fmt.Sprintf("relay %d", userID)
+}
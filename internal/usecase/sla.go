@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/markup"
+	"html"
+	"strings"
+	"time"
+)
+
+const slaCheckInterval = time.Minute
+
+type SLAService interface {
+	// Run - блокирующий цикл, оповещающий чат администраторов о просроченных вопросах
+	Run(ctx context.Context)
+
+	// GetStats - tagID = 0 означает без фильтра по тегу
+	GetStats(ctx context.Context, period entity.StatsPeriod, tagID int) ([]entity.ResponseStats, error)
+	// FormatStats - tag равен nil, если статистика не отфильтрована по тегу
+	FormatStats(period entity.StatsPeriod, tag *entity.Tag, stats []entity.ResponseStats) string
+}
+
+type slaService struct {
+	questionRepo  repo.QuestionRepo
+	transactor    repo.Transactor
+	outboxService OutboxService
+	log           *logger.Logger
+
+	adminChatID int64
+	timeout     time.Duration
+}
+
+func NewSLAService(
+	questionRepo repo.QuestionRepo,
+	transactor repo.Transactor,
+	outboxService OutboxService,
+	log *logger.Logger,
+	adminChatID int64,
+	timeout time.Duration,
+) (SLAService, error) {
+	if questionRepo == nil {
+		return nil, errors.New("questionRepo is nil")
+	}
+	if transactor == nil {
+		return nil, errors.New("transactor is nil")
+	}
+	if outboxService == nil {
+		return nil, errors.New("outboxService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &slaService{
+		questionRepo:  questionRepo,
+		transactor:    transactor,
+		outboxService: outboxService,
+		log:           log,
+		adminChatID:   adminChatID,
+		timeout:       timeout,
+	}, nil
+}
+
+func (s *slaService) Run(ctx context.Context) {
+	if s.timeout <= 0 || s.adminChatID == 0 {
+		s.log.Info("SLA alerts are disabled")
+		return
+	}
+
+	ticker := time.NewTicker(slaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.alertOverdue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *slaService) alertOverdue(ctx context.Context) {
+	now := time.Now().Local()
+
+	questions, err := s.questionRepo.GetOverdue(ctx, s.timeout)
+	if err != nil {
+		s.log.Error("questionRepo.GetOverdue: %v", err)
+		return
+	}
+
+	var queued bool
+	for _, q := range questions {
+		text := fmt.Sprintf("⚠️ Вопрос #%d без ответа уже %s\n\n%s",
+			q.ID, s.formatDuration(now.Sub(q.CreatedAt)), html.EscapeString(q.Question))
+
+		cardMarkup := markup.QuestionOpen(q.ID)
+		// оповещение и отметка о нем сохраняются вместе, чтобы оно не ушло дважды
+		err := s.transactor.WithTx(ctx, func(ctx context.Context) error {
+			if err := s.outboxService.Enqueue(ctx, s.adminChatID, &cardMarkup, text, 0); err != nil {
+				return err
+			}
+			return s.questionRepo.UpdateSLAAlerted(ctx, q.ID)
+		})
+		if err != nil {
+			s.log.Error("failed to queue SLA alert of question %d: %v", q.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		s.outboxService.Notify()
+	}
+}
+
+func (s *slaService) GetStats(ctx context.Context, period entity.StatsPeriod, tagID int) ([]entity.ResponseStats, error) {
+	now := time.Now().Local()
+	return s.questionRepo.GetResponseStats(ctx, now.Add(-period.Duration()), now, tagID)
+}
+
+func (s *slaService) FormatStats(period entity.StatsPeriod, tag *entity.Tag, stats []entity.ResponseStats) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<b>Скорость ответов за %s</b>\n", period.Title()))
+	if tag != nil {
+		sb.WriteString(fmt.Sprintf("Тег: #%s\n", html.EscapeString(tag.Name)))
+	}
+	if s.timeout > 0 {
+		sb.WriteString(fmt.Sprintf("Норматив ответа: %s\n", s.formatDuration(s.timeout)))
+	}
+	sb.WriteString("\n")
+
+	if len(stats) == 0 {
+		sb.WriteString("За период нет обработанных вопросов")
+		return sb.String()
+	}
+
+	for _, st := range stats {
+		title := "Все администраторы"
+		if st.AdminID != 0 {
+			title = fmt.Sprint(st.AdminID)
+			if st.AdminUsername != "" {
+				title = "@" + st.AdminUsername
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(title)))
+		sb.WriteString(fmt.Sprintf("  первая реакция: %d, в среднем %s\n", st.FirstActions, s.formatDuration(st.AvgFirstAction)))
+		sb.WriteString(fmt.Sprintf("  ответы: %d, в среднем %s\n", st.Answers, s.formatDuration(st.AvgAnswer)))
+	}
+
+	return sb.String()
+}
+
+// formatDuration - 0 означает, что данных нет. Отрицательная длительность получается, только если
+// время записано по разным часам: расхождение пишется в лог, а показывается 0 минут
+func (s *slaService) formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "—"
+	}
+	if d < 0 {
+		s.log.Error("negative SLA duration %s, check the clocks of the bot and the database", d)
+		d = 0
+	}
+
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dд %dч", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dч %dм", hours, minutes)
+	default:
+		return fmt.Sprintf("%dм", minutes)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxTagLen = 64
+
+type TagService interface {
+	CreateTag(ctx context.Context, actorID int64, name string) (*entity.Tag, error)
+	DeleteTag(ctx context.Context, actorID int64, id int) error
+
+	GetAllTags(ctx context.Context) ([]entity.Tag, error)
+	GetQuestionTags(ctx context.Context, questionID int) ([]entity.Tag, error)
+
+	// ToggleQuestionTag - возвращает true, если тег был добавлен, и false, если снят.
+	// Ручные исправления автоматической разметки записываются для настройки правил
+	ToggleQuestionTag(ctx context.Context, actorID int64, questionID, tagID int) (bool, error)
+}
+
+type tagService struct {
+	tagRepo      repo.TagRepo
+	tagRuleRepo  repo.TagRuleRepo
+	auditService AuditService
+	log          *logger.Logger
+}
+
+func NewTagService(tagRepo repo.TagRepo, tagRuleRepo repo.TagRuleRepo, auditService AuditService, log *logger.Logger) (TagService, error) {
+	if tagRepo == nil {
+		return nil, errors.New("tagRepo is nil")
+	}
+	if tagRuleRepo == nil {
+		return nil, errors.New("tagRuleRepo is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &tagService{
+		tagRepo:      tagRepo,
+		tagRuleRepo:  tagRuleRepo,
+		auditService: auditService,
+		log:          log,
+	}, nil
+}
+
+// NormalizeTag - теги хранятся в нижнем регистре без # и лишних пробелов
+func NormalizeTag(name string) string {
+	name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func (t *tagService) CreateTag(ctx context.Context, actorID int64, name string) (*entity.Tag, error) {
+	name = NormalizeTag(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTagLen {
+		return nil, customErr.ErrInvalidRequest
+	}
+
+	id, err := t.tagRepo.Create(ctx, name)
+	if err != nil {
+		t.log.Error("tagRepo.Create: failed to create tag %s: %v", name, err)
+		return nil, err
+	}
+
+	t.auditService.Log(ctx, actorID, entity.AuditTagCreate, name, nil)
+
+	return &entity.Tag{ID: id, Name: name}, nil
+}
+
+func (t *tagService) DeleteTag(ctx context.Context, actorID int64, id int) error {
+	tag, err := t.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		t.log.Error("tagRepo.GetByID: failed to get tag %d: %v", id, err)
+		return err
+	}
+
+	if err := t.tagRepo.Delete(ctx, id); err != nil {
+		t.log.Error("tagRepo.Delete: failed to delete tag %d: %v", id, err)
+		return err
+	}
+
+	t.auditService.Log(ctx, actorID, entity.AuditDelete, "tag "+tag.Name, entity.AuditDiff{
+		"tag": {Old: tag.Name},
+	})
+
+	return nil
+}
+
+func (t *tagService) GetAllTags(ctx context.Context) ([]entity.Tag, error) {
+	return t.tagRepo.GetAll(ctx)
+}
+
+func (t *tagService) GetQuestionTags(ctx context.Context, questionID int) ([]entity.Tag, error) {
+	return t.tagRepo.GetByQuestion(ctx, questionID)
+}
+
+func (t *tagService) ToggleQuestionTag(ctx context.Context, actorID int64, questionID, tagID int) (bool, error) {
+	tags, err := t.tagRepo.GetAssignments(ctx, questionID)
+	if err != nil {
+		t.log.Error("tagRepo.GetAssignments: failed to get tags of question %d: %v", questionID, err)
+		return false, err
+	}
+
+	for _, tag := range tags {
+		if tag.ID == tagID {
+			if err := t.tagRepo.RemoveFromQuestion(ctx, questionID, tagID); err != nil {
+				return false, err
+			}
+			if tag.IsAuto() {
+				t.logCorrection(ctx, actorID, questionID, tagID, tag.RuleID, entity.TagCorrectionRemoved)
+			}
+			return false, nil
+		}
+	}
+
+	if err := t.tagRepo.AddToQuestion(ctx, questionID, tagID); err != nil {
+		return false, err
+	}
+	t.logCorrection(ctx, actorID, questionID, tagID, 0, entity.TagCorrectionAdded)
+
+	return true, nil
+}
+
+func (t *tagService) logCorrection(ctx context.Context, actorID int64, questionID, tagID, ruleID int, action entity.TagCorrectionAction) {
+	if err := t.tagRuleRepo.CreateCorrection(ctx, &entity.TagCorrection{
+		QuestionID: questionID,
+		TagID:      tagID,
+		RuleID:     ruleID,
+		Action:     action,
+		AdminID:    actorID,
+	}); err != nil {
+		t.log.Error("tagRuleRepo.CreateCorrection: failed to log correction of question %d: %v", questionID, err)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/text"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type TagRuleService interface {
+	// CreateRule - input: первая строка "#тег [keyword|regex] [вес]", далее по одному признаку на строку
+	CreateRule(ctx context.Context, actorID int64, input string) (*entity.TagRule, error)
+	DeleteRule(ctx context.Context, actorID int64, id int) error
+	GetRules(ctx context.Context) ([]entity.TagRule, []entity.TagRuleStats, error)
+
+	// Classify - проставляет вопросу теги по правилам, возвращает число поставленных тегов
+	Classify(ctx context.Context, questionID int, question string) (int, error)
+}
+
+type tagRuleService struct {
+	tagRuleRepo  repo.TagRuleRepo
+	tagRepo      repo.TagRepo
+	auditService AuditService
+	log          *logger.Logger
+
+	minConfidence float64
+}
+
+func NewTagRuleService(
+	tagRuleRepo repo.TagRuleRepo,
+	tagRepo repo.TagRepo,
+	auditService AuditService,
+	log *logger.Logger,
+	minConfidence float64,
+) (TagRuleService, error) {
+	if tagRuleRepo == nil {
+		return nil, errors.New("tagRuleRepo is nil")
+	}
+	if tagRepo == nil {
+		return nil, errors.New("tagRepo is nil")
+	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &tagRuleService{
+		tagRuleRepo:   tagRuleRepo,
+		tagRepo:       tagRepo,
+		auditService:  auditService,
+		log:           log,
+		minConfidence: minConfidence,
+	}, nil
+}
+
+func (t *tagRuleService) CreateRule(ctx context.Context, actorID int64, input string) (*entity.TagRule, error) {
+	rule, tagName, err := parseRule(input)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := t.tagRepo.GetByName(ctx, tagName)
+	if err != nil {
+		t.log.Error("tagRepo.GetByName: failed to get tag %s: %v", tagName, err)
+		return nil, err
+	}
+	rule.TagID, rule.TagName, rule.CreatedBy = tag.ID, tag.Name, actorID
+
+	rule.ID, err = t.tagRuleRepo.Create(ctx, rule)
+	if err != nil {
+		t.log.Error("tagRuleRepo.Create: failed to create rule for tag %s: %v", tag.Name, err)
+		return nil, err
+	}
+
+	t.auditService.Log(ctx, actorID, entity.AuditTagRuleCreate, ruleTarget(rule), entity.AuditDiff{
+		"kind":     {New: rule.Kind},
+		"patterns": {New: rule.Patterns},
+		"weight":   {New: rule.Weight},
+	})
+
+	return rule, nil
+}
+
+func (t *tagRuleService) DeleteRule(ctx context.Context, actorID int64, id int) error {
+	rule, err := t.tagRuleRepo.GetByID(ctx, id)
+	if err != nil {
+		t.log.Error("tagRuleRepo.GetByID: failed to get rule %d: %v", id, err)
+		return err
+	}
+
+	if err := t.tagRuleRepo.Delete(ctx, id); err != nil {
+		t.log.Error("tagRuleRepo.Delete: failed to delete rule %d: %v", id, err)
+		return err
+	}
+
+	t.auditService.Log(ctx, actorID, entity.AuditDelete, ruleTarget(rule), entity.AuditDiff{
+		"patterns": {Old: rule.Patterns},
+	})
+
+	return nil
+}
+
+func (t *tagRuleService) GetRules(ctx context.Context) ([]entity.TagRule, []entity.TagRuleStats, error) {
+	rules, err := t.tagRuleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats, err := t.tagRuleRepo.GetStats(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rules, stats, nil
+}
+
+func (t *tagRuleService) Classify(ctx context.Context, questionID int, question string) (int, error) {
+	rules, err := t.tagRuleRepo.GetAll(ctx)
+	if err != nil {
+		t.log.Error("tagRuleRepo.GetAll: %v", err)
+		return 0, err
+	}
+
+	var (
+		stems    = text.Stems(question)
+		assigned = make(map[int]struct{})
+	)
+	for _, rule := range rules {
+		// тег остается за первым сработавшим правилом
+		if _, ok := assigned[rule.TagID]; ok {
+			continue
+		}
+
+		confidence := ruleConfidence(rule, matchRule(rule, stems, question))
+		if confidence < t.minConfidence || confidence == 0 {
+			continue
+		}
+
+		if err := t.tagRepo.AddAuto(ctx, questionID, rule.TagID, rule.ID, confidence); err != nil {
+			t.log.Error("tagRepo.AddAuto: failed to tag question %d by rule %d: %v", questionID, rule.ID, err)
+			continue
+		}
+		assigned[rule.TagID] = struct{}{}
+	}
+
+	return len(assigned), nil
+}
+
+// matchRule - число различных признаков правила, найденных в вопросе
+func matchRule(rule entity.TagRule, stems []string, question string) int {
+	hits := 0
+	for _, pattern := range rule.Patterns {
+		switch rule.Kind {
+		case entity.TagRuleKeyword:
+			if text.ContainsPhrase(stems, pattern) {
+				hits++
+			}
+		case entity.TagRuleRegex:
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err == nil && re.MatchString(question) {
+				hits++
+			}
+		}
+	}
+	return hits
+}
+
+// ruleConfidence - каждый следующий найденный признак вдвое сокращает оставшуюся неуверенность:
+// 1 признак - половина веса правила, 2 - три четверти и т.д.
+func ruleConfidence(rule entity.TagRule, hits int) float64 {
+	return rule.Weight * (1 - math.Pow(0.5, float64(hits)))
+}
+
+func parseRule(input string) (*entity.TagRule, string, error) {
+	lines := strings.Split(strings.TrimSpace(input), "\n")
+	if len(lines) < 2 {
+		return nil, "", customErr.ErrInvalidRequest
+	}
+
+	header := strings.Fields(lines[0])
+	if len(header) == 0 || len(header) > 3 {
+		return nil, "", customErr.ErrInvalidRequest
+	}
+
+	rule := &entity.TagRule{
+		Kind:   entity.TagRuleKeyword,
+		Weight: 1,
+	}
+	tagName := NormalizeTag(header[0])
+
+	if len(header) > 1 {
+		rule.Kind = entity.TagRuleKind(strings.ToLower(header[1]))
+		if !rule.Kind.IsValid() {
+			return nil, "", customErr.ErrInvalidRequest
+		}
+	}
+	if len(header) > 2 {
+		weight, err := strconv.ParseFloat(strings.Replace(header[2], ",", ".", 1), 64)
+		if err != nil || weight <= 0 || weight > 1 {
+			return nil, "", customErr.ErrInvalidRequest
+		}
+		rule.Weight = weight
+	}
+
+	for _, line := range lines[1:] {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" {
+			continue
+		}
+		if rule.Kind == entity.TagRuleRegex {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, "", customErr.ErrInvalidRequest
+			}
+		}
+		rule.Patterns = append(rule.Patterns, pattern)
+	}
+	if len(rule.Patterns) == 0 {
+		return nil, "", customErr.ErrInvalidRequest
+	}
+
+	return rule, tagName, nil
+}
+
+func ruleTarget(rule *entity.TagRule) string {
+	return fmt.Sprintf("rule %d #%s", rule.ID, rule.TagName)
+}
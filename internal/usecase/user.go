@@ -8,30 +8,33 @@ import (
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
 	customMsg "github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api"
+	"time"
 )
 
 type UserService interface {
 	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
 	GetAllUsers(ctx context.Context) ([]entity.User, error)
 	GetAllAdmin(ctx context.Context) ([]entity.User, error)
+	GetNewUsersBySource(ctx context.Context, from, to time.Time) (map[string]int, error)
+	GetUsersWithPermission(ctx context.Context, permission entity.Permission) ([]entity.User, error)
 
 	CreateUserIFNotExist(ctx context.Context, user *entity.User) error
 
-	UpdateRoleByUsername(ctx context.Context, role entity.UserRole, username string) error
-
-	// QUESTION domain
-	CreateQuestion(ctx context.Context, userID int64, text string) error
+	// UpdateRoleByUsername - возвращает пользователя с новой ролью
+	UpdateRoleByUsername(ctx context.Context, actorID int64, role entity.UserRole, username string) (*entity.User, error)
 }
 
 type userService struct {
-	userRepo repo.UserRepo
-	log      *logger.Logger
-	pg       *postgres.Postgres
-	tgMsg    customMsg.Message
+	userRepo     repo.UserRepo
+	auditService AuditService
+	log          *logger.Logger
+	pg           *postgres.Postgres
+	tgMsg        customMsg.Message
 }
 
 func NewUserService(
 	userRepo repo.UserRepo,
+	auditService AuditService,
 	log *logger.Logger,
 	tgMsg customMsg.Message,
 	pg *postgres.Postgres,
@@ -39,6 +42,9 @@ func NewUserService(
 	if userRepo == nil {
 		return nil, errors.New("userRepo is nil")
 	}
+	if auditService == nil {
+		return nil, errors.New("auditService is nil")
+	}
 	if log == nil {
 		return nil, errors.New("log is nil")
 	}
@@ -50,10 +56,11 @@ func NewUserService(
 	}
 
 	return &userService{
-		userRepo: userRepo,
-		log:      log,
-		pg:       pg,
-		tgMsg:    tgMsg,
+		userRepo:     userRepo,
+		auditService: auditService,
+		log:          log,
+		pg:           pg,
+		tgMsg:        tgMsg,
 	}, nil
 }
 
@@ -65,6 +72,14 @@ func (u *userService) GetAllAdmin(ctx context.Context) ([]entity.User, error) {
 	return u.userRepo.GetAllAdmin(ctx)
 }
 
+func (u *userService) GetNewUsersBySource(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	return u.userRepo.CountCreatedBetweenBySource(ctx, from, to)
+}
+
+func (u *userService) GetUsersWithPermission(ctx context.Context, permission entity.Permission) ([]entity.User, error) {
+	return u.userRepo.GetUsersWithPermission(ctx, permission)
+}
+
 func (u *userService) CreateUserIFNotExist(ctx context.Context, user *entity.User) error {
 	isExist, err := u.userRepo.IsUserExistByUserID(ctx, user.ID)
 	if err != nil {
@@ -88,23 +103,21 @@ func (u *userService) GetAllUsers(ctx context.Context) ([]entity.User, error) {
 	return u.userRepo.GetAllUsers(ctx)
 }
 
-func (u *userService) UpdateRoleByUsername(ctx context.Context, role entity.UserRole, username string) error {
-	return u.userRepo.UpdateRoleByUsername(ctx, role, username)
-}
-
-func (u *userService) CreateQuestion(ctx context.Context, userID int64, text string) error {
-	_, err := u.pg.Pool.Exec(ctx,
-		"INSERT INTO question ( user_id,question) VALUES ($1, $2)",
-		userID, text)
+func (u *userService) UpdateRoleByUsername(ctx context.Context, actorID int64, role entity.UserRole, username string) (*entity.User, error) {
+	user, err := u.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		u.log.Error("failed to insert question: ", err)
-		return nil
+		u.log.Error("userRepo.GetUserByUsername: failed to get user %s: %v", username, err)
+		return nil, err
 	}
 
-	if _, err := u.tgMsg.SendNewMessage(userID, nil, "Я получил ваше сообщение и отправил его аналитикам"); err != nil {
-		u.log.Error("failed to send new message: ", err)
-		return nil
+	if err := u.userRepo.UpdateRoleByUsername(ctx, role, username); err != nil {
+		return nil, err
 	}
 
-	return nil
+	u.auditService.Log(ctx, actorID, entity.AuditRoleChange, user.TGUsername, entity.AuditDiff{
+		"user_role": {Old: user.UserRole, New: role},
+	})
+
+	user.UserRole = role
+	return user, nil
 }
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/internal/entity"
+	"github.com/Enthreeka/tg-question-bot/internal/repo"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+)
+
+// BallotSize - сколько вопросов показывается пользователю за раз
+const BallotSize = 5
+
+type VoteService interface {
+	// GetBallot - случайные проверенные вопросы без ответа, за которые пользователь еще не голосовал
+	GetBallot(ctx context.Context, userID int64) ([]entity.Question, error)
+	// Vote - false, если пользователь уже голосовал за вопрос
+	Vote(ctx context.Context, userID int64, questionID int) (bool, error)
+}
+
+type voteService struct {
+	voteRepo     repo.VoteRepo
+	questionRepo repo.QuestionRepo
+	userRepo     repo.UserRepo
+	log          *logger.Logger
+}
+
+func NewVoteService(voteRepo repo.VoteRepo, questionRepo repo.QuestionRepo, userRepo repo.UserRepo, log *logger.Logger) (VoteService, error) {
+	if voteRepo == nil {
+		return nil, errors.New("voteRepo is nil")
+	}
+	if questionRepo == nil {
+		return nil, errors.New("questionRepo is nil")
+	}
+	if userRepo == nil {
+		return nil, errors.New("userRepo is nil")
+	}
+	if log == nil {
+		return nil, errors.New("log is nil")
+	}
+
+	return &voteService{
+		voteRepo:     voteRepo,
+		questionRepo: questionRepo,
+		userRepo:     userRepo,
+		log:          log,
+	}, nil
+}
+
+func (v *voteService) GetBallot(ctx context.Context, userID int64) ([]entity.Question, error) {
+	return v.questionRepo.GetForVoting(ctx, userID, BallotSize)
+}
+
+func (v *voteService) Vote(ctx context.Context, userID int64, questionID int) (bool, error) {
+	user, err := v.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		v.log.Error("userRepo.GetUserByID: failed to get user %d: %v", userID, err)
+		return false, err
+	}
+	if user.IsBanned {
+		return false, customErr.ErrIsNotAdmin
+	}
+
+	question, err := v.questionRepo.GetByID(ctx, questionID)
+	if err != nil {
+		v.log.Error("questionRepo.GetByID: failed to get question %d: %v", questionID, err)
+		return false, err
+	}
+	// голосовать можно только за проверенные вопросы, ожидающие ответа
+	if question.Status != entity.QuestionChecked {
+		return false, customErr.ErrInvalidRequest
+	}
+
+	ok, err := v.voteRepo.Create(ctx, question.RootID(), userID)
+	if err != nil {
+		v.log.Error("voteRepo.Create: failed to vote for question %d: %v", questionID, err)
+		return false, err
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,12 @@
+create table if not exists audit_log
+(
+    id         bigint generated always as identity,
+    actor_id   bigint       not null,
+    action     varchar(50)  not null,
+    target     text         not null,
+    created_at timestamp    not null default now(),
+    diff       jsonb        null,
+    primary key (id)
+);
+
+create index if not exists audit_log_created_at_idx on audit_log (created_at desc);
@@ -0,0 +1,54 @@
+alter type role add value if not exists 'analyst';
+alter type role add value if not exists 'moderator';
+alter type role add value if not exists 'editor';
+
+create table if not exists permission
+(
+    name        varchar(50) not null,
+    description text        not null,
+    primary key (name)
+);
+
+create table if not exists role_permission
+(
+    role       role        not null,
+    permission varchar(50) not null,
+    primary key (role, permission),
+    foreign key (permission)
+        references permission (name) on delete cascade
+);
+
+insert into permission (name, description)
+values ('panel.access', 'Доступ к панели управления'),
+       ('question.read', 'Просмотр вопросов'),
+       ('question.answer', 'Ответы на вопросы'),
+       ('question.export', 'Выгрузка вопросов с персональными данными'),
+       ('question.publish', 'Публикация ответов'),
+       ('user.ban', 'Блокировка пользователей'),
+       ('role.manage', 'Управление ролями'),
+       ('audit.read', 'Просмотр журнала действий')
+on conflict (name) do nothing;
+
+insert into role_permission (role, permission)
+select 'superAdmin', name
+from permission
+on conflict do nothing;
+
+insert into role_permission (role, permission)
+values ('admin', 'panel.access'),
+       ('admin', 'question.read'),
+       ('admin', 'question.answer'),
+       ('admin', 'question.export'),
+       ('admin', 'question.publish'),
+       ('admin', 'user.ban'),
+       ('admin', 'role.manage'),
+       ('analyst', 'panel.access'),
+       ('analyst', 'question.read'),
+       ('analyst', 'question.answer'),
+       ('moderator', 'panel.access'),
+       ('moderator', 'question.read'),
+       ('moderator', 'user.ban'),
+       ('editor', 'panel.access'),
+       ('editor', 'question.read'),
+       ('editor', 'question.publish')
+on conflict do nothing;
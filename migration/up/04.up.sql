@@ -0,0 +1,12 @@
+alter table "user"
+    add column if not exists is_banned boolean default false not null;
+
+alter table question
+    add column if not exists status            varchar(20) default 'new' not null,
+    add column if not exists created_at        timestamp   default now() not null,
+    add column if not exists answer            text        null,
+    add column if not exists answered_by       bigint      null,
+    add column if not exists notify_chat_id    bigint      null,
+    add column if not exists notify_message_id int         null;
+
+create index if not exists question_notify_idx on question (notify_chat_id, notify_message_id);
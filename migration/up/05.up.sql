@@ -0,0 +1,15 @@
+create table if not exists digest_subscription
+(
+    user_id bigint      not null,
+    kind    varchar(20) not null,
+    primary key (user_id, kind),
+    foreign key (user_id)
+        references "user" (id) on delete cascade
+);
+
+create table if not exists digest_state
+(
+    kind         varchar(20) not null,
+    last_sent_at timestamp   not null,
+    primary key (kind)
+);
@@ -0,0 +1,5 @@
+alter table question
+    add column if not exists assignee_id bigint    null references "user" (id) on delete set null,
+    add column if not exists claimed_at  timestamp null;
+
+create index if not exists question_assignee_idx on question (assignee_id);
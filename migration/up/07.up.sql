@@ -0,0 +1,9 @@
+alter table question
+    add column if not exists first_action_at timestamp null,
+    add column if not exists first_action_by bigint    null,
+    add column if not exists checked_at      timestamp null,
+    add column if not exists answered_at     timestamp null,
+    add column if not exists published_at    timestamp null,
+    add column if not exists sla_alerted_at  timestamp null;
+
+create index if not exists question_created_at_idx on question (created_at);
@@ -0,0 +1,29 @@
+create table if not exists tag
+(
+    id   int generated always as identity,
+    name varchar(64) not null unique,
+    primary key (id)
+);
+
+create table if not exists question_tag
+(
+    question_id int not null,
+    tag_id      int not null,
+    primary key (question_id, tag_id),
+    foreign key (question_id)
+        references question (id) on delete cascade,
+    foreign key (tag_id)
+        references tag (id) on delete cascade
+);
+
+create index if not exists question_tag_tag_idx on question_tag (tag_id);
+
+insert into permission (name, description)
+values ('tag.manage', 'Управление словарем тегов')
+on conflict (name) do nothing;
+
+insert into role_permission (role, permission)
+values ('superAdmin', 'tag.manage'),
+       ('admin', 'tag.manage'),
+       ('editor', 'tag.manage')
+on conflict do nothing;
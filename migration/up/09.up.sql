@@ -0,0 +1,37 @@
+create table if not exists tag_rule
+(
+    id         int generated always as identity,
+    tag_id     int              not null,
+    kind       varchar(10)      not null,
+    patterns   text[]           not null,
+    weight     double precision not null default 1,
+    position   int              not null,
+    created_by bigint           null,
+    created_at timestamp        not null default now(),
+    primary key (id),
+    foreign key (tag_id)
+        references tag (id) on delete cascade
+);
+
+alter table question_tag
+    add column if not exists rule_id    int              null,
+    add column if not exists confidence double precision null,
+    add column if not exists created_at timestamp        not null default now();
+
+create table if not exists tag_correction
+(
+    id          int generated always as identity,
+    question_id int         not null,
+    tag_id      int         not null,
+    rule_id     int         null,
+    action      varchar(10) not null,
+    admin_id    bigint      not null,
+    created_at  timestamp   not null default now(),
+    primary key (id),
+    foreign key (question_id)
+        references question (id) on delete cascade,
+    foreign key (tag_id)
+        references tag (id) on delete cascade
+);
+
+create index if not exists tag_correction_rule_idx on tag_correction (rule_id);
@@ -0,0 +1,7 @@
+create extension if not exists pg_trgm;
+
+alter table question
+    add column if not exists cluster_id int null references question (id) on delete set null;
+
+create index if not exists question_cluster_idx on question (cluster_id);
+create index if not exists question_trgm_idx on question using gin (question gin_trgm_ops);
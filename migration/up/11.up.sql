@@ -0,0 +1,5 @@
+alter table question
+    add column if not exists search_vector tsvector
+        generated always as (to_tsvector('russian', question || ' ' || coalesce(answer, ''))) stored;
+
+create index if not exists question_search_idx on question using gin (search_vector);
@@ -0,0 +1,11 @@
+create table if not exists question_vote
+(
+    question_id int       not null,
+    user_id     bigint    not null,
+    created_at  timestamp not null default now(),
+    primary key (question_id, user_id),
+    foreign key (question_id)
+        references question (id) on delete cascade,
+    foreign key (user_id)
+        references "user" (id) on delete cascade
+);
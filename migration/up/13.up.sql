@@ -0,0 +1,35 @@
+create table if not exists faq
+(
+    id         int generated always as identity,
+    patterns   text[]    not null,
+    answer     text      not null,
+    created_by bigint    null,
+    created_at timestamp not null default now(),
+    primary key (id)
+);
+
+create table if not exists faq_suggestion
+(
+    id          int generated always as identity,
+    faq_id      int         not null,
+    user_id     bigint      not null,
+    question    text        not null,
+    outcome     varchar(10) null,
+    created_at  timestamp   not null default now(),
+    resolved_at timestamp   null,
+    primary key (id),
+    foreign key (faq_id)
+        references faq (id) on delete cascade
+);
+
+create index if not exists faq_suggestion_faq_idx on faq_suggestion (faq_id);
+
+insert into permission (name, description)
+values ('faq.manage', 'Управление подсказками FAQ')
+on conflict (name) do nothing;
+
+insert into role_permission (role, permission)
+values ('superAdmin', 'faq.manage'),
+       ('admin', 'faq.manage'),
+       ('editor', 'faq.manage')
+on conflict do nothing;
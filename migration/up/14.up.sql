@@ -0,0 +1,30 @@
+create table if not exists question_thread
+(
+    id          int generated always as identity,
+    question_id int         not null,
+    kind        varchar(10) not null,
+    author_id   bigint      not null,
+    text        text        not null,
+    created_at  timestamp   not null default now(),
+    primary key (id),
+    foreign key (question_id)
+        references question (id) on delete cascade
+);
+
+create index if not exists question_thread_question_idx on question_thread (question_id, created_at);
+
+create table if not exists question_message
+(
+    chat_id     bigint not null,
+    message_id  int    not null,
+    question_id int    not null,
+    primary key (chat_id, message_id),
+    foreign key (question_id)
+        references question (id) on delete cascade
+);
+
+insert into question_thread (question_id, kind, author_id, text, created_at)
+select q.id, 'answer', coalesce(q.answered_by, 0), q.answer, coalesce(q.answered_at, q.created_at)
+from question q
+where coalesce(q.answer, '') <> ''
+  and not exists (select 1 from question_thread t where t.question_id = q.id);
@@ -0,0 +1,21 @@
+create table if not exists relay_topic
+(
+    user_id    bigint    not null,
+    chat_id    bigint    not null,
+    thread_id  int       not null,
+    is_open    boolean   not null default true,
+    created_at timestamp not null default now(),
+    closed_at  timestamp null,
+    primary key (user_id, chat_id),
+    unique (chat_id, thread_id),
+    foreign key (user_id)
+        references "user" (id) on delete cascade
+);
+
+create table if not exists relay_message
+(
+    chat_id    bigint not null,
+    message_id int    not null,
+    user_id    bigint not null,
+    primary key (chat_id, message_id)
+);
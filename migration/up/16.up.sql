@@ -0,0 +1,26 @@
+create table if not exists outbox
+(
+    id              bigint generated always as identity,
+    chat_id         bigint       not null,
+    text            text         not null,
+    markup          jsonb        null,
+    question_id     int          null,
+    status          varchar(10)  not null default 'pending',
+    attempts        int          not null default 0,
+    next_attempt_at timestamp    not null default now(),
+    last_error      text         null,
+    created_at      timestamp    not null default now(),
+    sent_at         timestamp    null,
+    primary key (id)
+);
+
+create index if not exists outbox_due_idx on outbox (next_attempt_at) where status = 'pending';
+
+insert into permission (name, description)
+values ('outbox.manage', 'Просмотр и повтор недоставленных сообщений')
+on conflict (name) do nothing;
+
+insert into role_permission (role, permission)
+values ('superAdmin', 'outbox.manage'),
+       ('admin', 'outbox.manage')
+on conflict do nothing;
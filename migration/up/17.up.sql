@@ -0,0 +1,9 @@
+create table if not exists conversation_state
+(
+    user_id    bigint    not null,
+    data       jsonb     not null,
+    expires_at timestamp not null,
+    primary key (user_id)
+);
+
+create index if not exists conversation_state_expires_idx on conversation_state (expires_at);
@@ -0,0 +1,3 @@
+-- null - состояние без срока жизни, STORE_TTL <= 0
+alter table conversation_state
+    alter column expires_at drop not null;
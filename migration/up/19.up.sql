@@ -0,0 +1,10 @@
+-- callback data, не поместившаяся в кнопку, см. action.Payloads
+create table if not exists callback_payload
+(
+    key        text      not null,
+    data       text      not null,
+    created_at timestamp not null default now(),
+    primary key (key)
+);
+
+create index if not exists callback_payload_created_idx on callback_payload (created_at);
@@ -12,6 +12,11 @@ const (
 	ForeignKeyViolation = "Foreign Key Violation"
 	UniqueViolation     = "Violation Must Be Unique"
 	AdminPermission     = "Permission Denied"
+	AlreadyClaimed      = "Already Claimed"
+	AssigneeNotAllowed  = "Assignee Not Allowed"
+	QuestionClosed      = "Question Closed"
+	RelayDisabled       = "Relay Disabled"
+	ButtonExpired       = "Button Expired"
 )
 
 var (
@@ -22,6 +27,11 @@ var (
 	ErrForeignKeyViolation = NewError(ForeignKeyViolation)
 	ErrUniqueViolation     = NewError(UniqueViolation)
 	ErrIsNotAdmin          = NewError(AdminPermission)
+	ErrAlreadyClaimed      = NewError(AlreadyClaimed)
+	ErrAssigneeNotAllowed  = NewError(AssigneeNotAllowed)
+	ErrQuestionClosed      = NewError(QuestionClosed)
+	ErrRelayDisabled       = NewError(RelayDisabled)
+	ErrButtonExpired       = NewError(ButtonExpired)
 )
 
 type ErrorCode string
@@ -50,6 +60,16 @@ func parseErrToText(err ErrorCode) string {
 		return "Поисковая сущность отсутствует"
 	case AdminPermission:
 		return "Недостаточно прав доступа"
+	case AlreadyClaimed:
+		return "Вопрос уже взят в работу другим администратором"
+	case AssigneeNotAllowed:
+		return "Этот пользователь не может отвечать на вопросы"
+	case QuestionClosed:
+		return "Вопрос уже закрыт"
+	case RelayDisabled:
+		return "Группа поддержки не настроена"
+	case ButtonExpired:
+		return "Кнопка устарела, откройте меню заново"
 	case NoRows, ForeignKeyViolation, UniqueViolation:
 		return "Ошибка связанная с базой данных"
 	default:
@@ -25,6 +25,20 @@ type Question struct {
 	ID       int
 	UserID   int
 	Question string
+	Status   string
+	Tags     string
+	Votes    int
+	Thread   string
+}
+
+type Audit struct {
+	ID            int64
+	CreatedAt     time.Time
+	ActorID       int64
+	ActorUsername string
+	Action        string
+	Target        string
+	Diff          string
 }
 
 func (e *Excel) GenerateUserResultsExcelFile(results []Question, username string) (string, error) {
@@ -45,6 +59,10 @@ func (e *Excel) GenerateUserResultsExcelFile(results []Question, username string
 		"A1": "ID вопроса",
 		"B1": "ID пользователя",
 		"C1": "Вопрос",
+		"D1": "Статус",
+		"E1": "Теги",
+		"F1": "Голоса",
+		"G1": "Переписка",
 	}
 
 	for cell, value := range headers {
@@ -56,12 +74,63 @@ func (e *Excel) GenerateUserResultsExcelFile(results []Question, username string
 		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.ID)
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), result.UserID)
 		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), result.Question)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), result.Status)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), result.Tags)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), result.Votes)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), result.Thread)
+	}
+
+	filename, err := e.saveTemp(f, "question_result_*.xlsx")
+	if err != nil {
+		return "", err
+	}
+
+	end := time.Since(start)
+	e.log.Info("[%s] by [%s] Время генерации файла: %f", filename, username, end.Seconds())
+	return filename, nil
+}
+
+func (e *Excel) GenerateAuditExcelFile(logs []Audit, username string) (string, error) {
+	start := time.Now()
+
+	f := excelize.NewFile()
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			e.log.Error("failed to close excel: %v", err)
+		}
+	}()
+
+	sheetName := "Sheet1"
+	f.NewSheet(sheetName)
+
+	headers := map[string]string{
+		"A1": "ID записи",
+		"B1": "Дата",
+		"C1": "ID администратора",
+		"D1": "Администратор",
+		"E1": "Действие",
+		"F1": "Объект",
+		"G1": "Изменения",
+	}
+
+	for cell, value := range headers {
+		f.SetCellValue(sheetName, cell, value)
+	}
+
+	for i, l := range logs {
+		row := i + 2
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), l.ID)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), l.CreatedAt.Format("02.01.2006 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), l.ActorID)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), l.ActorUsername)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), l.Action)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), l.Target)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), l.Diff)
 	}
 
-	filename := fmt.Sprintf("question_result.xlsx")
-	err := f.SaveAs(filename)
+	filename, err := e.saveTemp(f, "audit_log_*.xlsx")
 	if err != nil {
-		e.log.Error("failed to save file: %s", filename)
 		return "", err
 	}
 
@@ -70,6 +139,36 @@ func (e *Excel) GenerateUserResultsExcelFile(results []Question, username string
 	return filename, nil
 }
 
+// saveTemp - файл с уникальным именем, чтобы параллельные выгрузки не перезаписывали друг друга.
+// После отправки его нужно удалить через RemoveExcelFile
+func (e *Excel) saveTemp(f *excelize.File, pattern string) (string, error) {
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		e.log.Error("os.CreateTemp: failed to create file: %v", err)
+		return "", err
+	}
+
+	if err := f.Write(file); err != nil {
+		e.log.Error("failed to save file: %s", file.Name())
+		_ = file.Close()
+		e.RemoveExcelFile(file.Name())
+		return "", err
+	}
+
+	if err := file.Close(); err != nil {
+		e.log.Error("failed to close file %s: %v", file.Name(), err)
+		e.RemoveExcelFile(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func (e *Excel) RemoveExcelFile(fileName string) {
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		e.log.Error("os.Remove: failed to remove file %s: %v", fileName, err)
+	}
+}
+
 func (e *Excel) GetExcelFile(fileName string) (*[]byte, error) {
 
 	file, err := os.Open(fileName)
@@ -0,0 +1,15 @@
+package store
+
+import "encoding/json"
+
+func encodeData(data *Data) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func decodeData(raw []byte) (*Data, error) {
+	data := new(Data)
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCodecRoundtrip(t *testing.T) {
+	want := &Data{
+		OperationType: "test",
+		Step:          2,
+		Payload:       json.RawMessage(`{"text":"вопрос","id":42}`),
+		ChatID:        -100123,
+		PreferMsgID:   10,
+		CurrentMsgID:  12,
+		MessageIDs:    []int{10, 11},
+		UpdatedAt:     time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	raw, err := encodeData(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decodeData(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeData(encodeData(data)) = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeDataInvalid(t *testing.T) {
+	if _, err := decodeData([]byte(`{"step":`)); err == nil {
+		t.Fatal("decodeData of malformed JSON returned no error")
+	}
+}
@@ -5,15 +5,29 @@ type OperationType string
 type TypeCommand string
 
 const (
-	Admin OperationType = "admin"
+	Admin    OperationType = "admin"
+	Question OperationType = "question"
+	Tag      OperationType = "tag"
+	Search   OperationType = "search"
+	Faq      OperationType = "faq"
 )
 
 const (
-	AdminCreate TypeCommand = "create"
-	AdminDelete TypeCommand = "delete"
+	AdminCreate    TypeCommand = "create"
+	AdminDelete    TypeCommand = "delete"
+	QuestionAnswer TypeCommand = "answer"
+	TagCreate      TypeCommand = "tag_create"
+	TagRuleCreate  TypeCommand = "tag_rule_create"
+	SearchQuery    TypeCommand = "search_query"
+	FaqCreate      TypeCommand = "faq_create"
 )
 
 var MapTypes = map[TypeCommand]OperationType{
-	AdminCreate: Admin,
-	AdminDelete: Admin,
+	AdminCreate:    Admin,
+	AdminDelete:    Admin,
+	QuestionAnswer: Question,
+	TagCreate:      Tag,
+	TagRuleCreate:  Tag,
+	SearchQuery:    Search,
+	FaqCreate:      Faq,
 }
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+const (
+	// PayloadTTL - сколько работает кнопка, callback data которой хранится на сервере
+	PayloadTTL = 30 * 24 * time.Hour
+
+	redisPayloadPrefix = "payload:"
+)
+
+// PostgresPayloads - хранилище action.Payloads в базе: кнопки продолжают работать после перезапуска
+// и на любом экземпляре бота
+type PostgresPayloads struct {
+	psql *postgres.Postgres
+	log  *logger.Logger
+
+	mu         sync.Mutex
+	lastPurged time.Time
+}
+
+func NewPostgresPayloads(psql *postgres.Postgres, log *logger.Logger) (*PostgresPayloads, error) {
+	if psql == nil {
+		return nil, errors.New("postgres is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	return &PostgresPayloads{
+		psql: psql,
+		log:  log,
+	}, nil
+}
+
+// Save - ключ выводится из данных, поэтому повторное сохранение только продлевает срок
+func (p *PostgresPayloads) Save(key string, data string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	query := `insert into callback_payload (key, data) values ($1, $2)
+			on conflict (key) do update set created_at = now()`
+
+	if _, err := p.psql.Pool.Exec(ctx, query, key, data); err != nil {
+		p.log.Error("failed to save callback payload %s: %v", key, err)
+	}
+
+	p.purgeExpired(ctx)
+}
+
+func (p *PostgresPayloads) Load(key string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	query := `select data from callback_payload where key = $1 and created_at > now() - make_interval(secs => $2::float8)`
+	var data string
+
+	if err := p.psql.Pool.QueryRow(ctx, query, key, PayloadTTL.Seconds()).Scan(&data); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			p.log.Error("failed to load callback payload %s: %v", key, err)
+		}
+		return "", false
+	}
+
+	return data, true
+}
+
+func (p *PostgresPayloads) purgeExpired(ctx context.Context) {
+	p.mu.Lock()
+	if time.Since(p.lastPurged) < purgeInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPurged = time.Now()
+	p.mu.Unlock()
+
+	query := `delete from callback_payload where created_at <= now() - make_interval(secs => $1::float8)`
+
+	if _, err := p.psql.Pool.Exec(ctx, query, PayloadTTL.Seconds()); err != nil {
+		p.log.Error("failed to delete expired callback payloads: %v", err)
+	}
+}
+
+// RedisPayloads - хранилище action.Payloads в Redis, срок жизни задается через EXPIRE
+type RedisPayloads struct {
+	client *redis.Client
+	log    *logger.Logger
+}
+
+func NewRedisPayloads(client *redis.Client, log *logger.Logger) (*RedisPayloads, error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	return &RedisPayloads{
+		client: client,
+		log:    log,
+	}, nil
+}
+
+func (r *RedisPayloads) Save(key string, data string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	if err := r.client.Set(ctx, redisPayloadPrefix+key, data, PayloadTTL).Err(); err != nil {
+		r.log.Error("failed to save callback payload %s: %v", key, err)
+	}
+}
+
+func (r *RedisPayloads) Load(key string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	data, err := r.client.Get(ctx, redisPayloadPrefix+key).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			r.log.Error("failed to load callback payload %s: %v", key, err)
+		}
+		return "", false
+	}
+
+	return data, true
+}
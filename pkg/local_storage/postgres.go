@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"sync"
+	"time"
+)
+
+const (
+	storeQueryTimeout = 3 * time.Second
+	// purgeInterval - как часто при записи удаляются истекшие состояния
+	purgeInterval = 10 * time.Minute
+)
+
+// PostgresStore - состояние переживает перезапуск и общее для нескольких экземпляров бота.
+// Ошибки базы логируются, и состояние считается отсутствующим
+type PostgresStore struct {
+	psql *postgres.Postgres
+	log  *logger.Logger
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	lastPurged time.Time
+}
+
+func NewPostgresStore(psql *postgres.Postgres, log *logger.Logger, ttl time.Duration) (*PostgresStore, error) {
+	if psql == nil {
+		return nil, errors.New("postgres is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	return &PostgresStore{
+		psql: psql,
+		log:  log,
+		ttl:  ttl,
+	}, nil
+}
+
+func (p *PostgresStore) Set(data *Data, userID int64) {
+	raw, err := encodeData(data)
+	if err != nil {
+		p.log.Error("failed to encode state of user %d: %v", userID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	// ttl <= 0 - без срока жизни, как в Store и RedisStore
+	query := `insert into conversation_state (user_id, data, expires_at)
+			values ($1, $2, case when $3::float8 > 0 then now() + make_interval(secs => $3::float8) end)
+			on conflict (user_id) do update set data = excluded.data, expires_at = excluded.expires_at`
+
+	if _, err := p.psql.Pool.Exec(ctx, query, userID, raw, p.ttl.Seconds()); err != nil {
+		p.log.Error("failed to save state of user %d: %v", userID, err)
+	}
+
+	p.purgeExpired(ctx)
+}
+
+func (p *PostgresStore) Read(userID int64) (*Data, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	query := `select data from conversation_state where user_id = $1 and (expires_at is null or expires_at > now())`
+	var raw []byte
+
+	if err := p.psql.Pool.QueryRow(ctx, query, userID).Scan(&raw); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			p.log.Error("failed to read state of user %d: %v", userID, err)
+		}
+		return nil, false
+	}
+
+	data, err := decodeData(raw)
+	if err != nil {
+		p.log.Error("failed to decode state of user %d: %v", userID, err)
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (p *PostgresStore) Delete(userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	query := `delete from conversation_state where user_id = $1`
+
+	if _, err := p.psql.Pool.Exec(ctx, query, userID); err != nil {
+		p.log.Error("failed to delete state of user %d: %v", userID, err)
+	}
+}
+
+func (p *PostgresStore) purgeExpired(ctx context.Context) {
+	p.mu.Lock()
+	if time.Since(p.lastPurged) < purgeInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPurged = time.Now()
+	p.mu.Unlock()
+
+	query := `delete from conversation_state where expires_at <= now()`
+
+	if _, err := p.psql.Pool.Exec(ctx, query); err != nil {
+		p.log.Error("failed to delete expired states: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/Enthreeka/tg-question-bot/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestPostgres - нужна база из TEST_POSTGRES_URL, без нее тест пропускается
+func newTestPostgres(t *testing.T) *postgres.Postgres {
+	t.Helper()
+
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	for _, migration := range []string{"../../migration/up/17.up.sql", "../../migration/up/18.up.sql", "../../migration/up/19.up.sql"} {
+		query, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(query)); err != nil {
+			t.Fatalf("%s: %v", migration, err)
+		}
+	}
+
+	return &postgres.Postgres{Pool: pool}
+}
+
+func newTestPostgresStore(t *testing.T, ttl time.Duration) *PostgresStore {
+	t.Helper()
+
+	s, err := NewPostgresStore(newTestPostgres(t), logger.New(), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPostgresStoreTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		wait     time.Duration
+		readable bool
+	}{
+		{name: "before ttl", ttl: time.Hour, readable: true},
+		{name: "after ttl", ttl: time.Second, wait: 1500 * time.Millisecond, readable: false},
+		{name: "zero ttl never expires", ttl: 0, wait: 1500 * time.Millisecond, readable: true},
+		{name: "negative ttl never expires", ttl: -time.Second, readable: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestPostgresStore(t, tt.ttl)
+			userID := -int64(1000 + i)
+			t.Cleanup(func() { s.Delete(userID) })
+
+			s.Set(&Data{OperationType: "test", Step: 3}, userID)
+			time.Sleep(tt.wait)
+
+			data, ok := s.Read(userID)
+			if ok != tt.readable {
+				t.Fatalf("Read ok = %v, want %v", ok, tt.readable)
+			}
+			if ok && data.Step != 3 {
+				t.Fatalf("Read step = %d, want 3", data.Step)
+			}
+		})
+	}
+}
+
+func TestPostgresPayloads(t *testing.T) {
+	p, err := NewPostgresPayloads(newTestPostgres(t), logger.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "~test-payload"
+	t.Cleanup(func() {
+		_, _ = p.psql.Pool.Exec(context.Background(), `delete from callback_payload where key = $1`, key)
+	})
+
+	if _, ok := p.Load(key); ok {
+		t.Fatal("Load of unsaved key succeeded")
+	}
+
+	p.Save(key, "search:long query:3")
+	p.Save(key, "search:long query:3")
+
+	if data, ok := p.Load(key); !ok || data != "search:long query:3" {
+		t.Fatalf("Load = %q, %v, want saved data", data, ok)
+	}
+}
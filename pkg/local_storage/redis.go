@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const redisKeyPrefix = "state:"
+
+// RedisStore - состояние хранится в Redis, срок жизни записи задается через EXPIRE
+type RedisStore struct {
+	client *redis.Client
+	log    *logger.Logger
+	ttl    time.Duration
+}
+
+func NewRedisStore(client *redis.Client, log *logger.Logger, ttl time.Duration) (*RedisStore, error) {
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if log == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	// ttl <= 0 - запись без EXPIRE. Отрицательное значение не передается в Set: -1 там означает KEEPTTL
+	return &RedisStore{
+		client: client,
+		log:    log,
+		ttl:    max(ttl, 0),
+	}, nil
+}
+
+func redisKey(userID int64) string {
+	return fmt.Sprintf("%s%d", redisKeyPrefix, userID)
+}
+
+func (r *RedisStore) Set(data *Data, userID int64) {
+	raw, err := encodeData(data)
+	if err != nil {
+		r.log.Error("failed to encode state of user %d: %v", userID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	if err := r.client.Set(ctx, redisKey(userID), raw, r.ttl).Err(); err != nil {
+		r.log.Error("failed to save state of user %d: %v", userID, err)
+	}
+}
+
+func (r *RedisStore) Read(userID int64) (*Data, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	raw, err := r.client.Get(ctx, redisKey(userID)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			r.log.Error("failed to read state of user %d: %v", userID, err)
+		}
+		return nil, false
+	}
+
+	data, err := decodeData(raw)
+	if err != nil {
+		r.log.Error("failed to decode state of user %d: %v", userID, err)
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (r *RedisStore) Delete(userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeQueryTimeout)
+	defer cancel()
+
+	if err := r.client.Del(ctx, redisKey(userID)).Err(); err != nil {
+		r.log.Error("failed to delete state of user %d: %v", userID, err)
+	}
+}
@@ -1,43 +1,67 @@
 package store
 
-import "sync"
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
 
+// Store - состояние в памяти процесса, теряется при перезапуске бота. Как и в других хранилищах,
+// состояние забывается через ttl после последней записи, ttl <= 0 - хранится без ограничения
 type Store struct {
-	store map[int64]*Data
+	store map[int64]memoryEntry
+	ttl   time.Duration
+	now   func() time.Time
 
-	mu sync.RWMutex
+	mu         sync.RWMutex
+	lastPurged time.Time
 }
 
+type memoryEntry struct {
+	data      *Data
+	expiresAt time.Time
+}
+
+// Data - состояние незавершенного диалога пользователя. Payload - данные сценария в JSON,
+// ChatID - чат, в котором идет диалог, MessageIDs - уже пройденные приглашения и ответы на них
 type Data struct {
-	Data          interface{}
-	OperationType TypeCommand
-	PreferMsgID   int
-	CurrentMsgID  int
+	OperationType TypeCommand     `json:"operation_type"`
+	Step          int             `json:"step"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	ChatID        int64           `json:"chat_id"`
+	PreferMsgID   int             `json:"prefer_msg_id"`
+	CurrentMsgID  int             `json:"current_msg_id"`
+	MessageIDs    []int           `json:"message_ids,omitempty"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
-func NewStore() *Store {
+func NewStore(ttl time.Duration) *Store {
 	return &Store{
-		store: make(map[int64]*Data, 30),
+		store: make(map[int64]memoryEntry, 30),
+		ttl:   ttl,
+		now:   time.Now,
 	}
 }
 
 func (s *Store) Set(data *Data, userID int64) {
-	s.Delete(userID)
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.store[userID] = data
+
+	now := s.now()
+	s.store[userID] = memoryEntry{data: data, expiresAt: now.Add(s.ttl)}
+	s.purgeExpired(now)
 }
 
 func (s *Store) Read(userID int64) (*Data, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	d, ok := s.store[userID]
-	if !ok {
+	entry, ok := s.store[userID]
+	if !ok || s.expired(entry, s.now()) {
 		return nil, false
 	}
 
-	return d, true
+	return entry.data, true
 }
 
 func (s *Store) Delete(userID int64) {
@@ -45,3 +69,21 @@ func (s *Store) Delete(userID int64) {
 	defer s.mu.Unlock()
 	delete(s.store, userID)
 }
+
+func (s *Store) expired(entry memoryEntry, now time.Time) bool {
+	return s.ttl > 0 && !now.Before(entry.expiresAt)
+}
+
+// purgeExpired - вызывается под s.mu при записи, не чаще purgeInterval
+func (s *Store) purgeExpired(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastPurged) < purgeInterval {
+		return
+	}
+	s.lastPurged = now
+
+	for userID, entry := range s.store {
+		if s.expired(entry, now) {
+			delete(s.store, userID)
+		}
+	}
+}
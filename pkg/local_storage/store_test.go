@@ -0,0 +1,77 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestStore(ttl time.Duration) (*Store, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(ttl)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestStoreExpiresAfterTTL(t *testing.T) {
+	s, now := newTestStore(time.Hour)
+	s.Set(&Data{OperationType: "test", Step: 1}, 1)
+
+	*now = now.Add(59 * time.Minute)
+	if data, ok := s.Read(1); !ok || data.Step != 1 {
+		t.Fatalf("Read before ttl = %+v, %v, want stored state", data, ok)
+	}
+
+	*now = now.Add(time.Minute)
+	if data, ok := s.Read(1); ok {
+		t.Fatalf("Read after ttl = %+v, want no state", data)
+	}
+}
+
+func TestStoreSetRefreshesTTL(t *testing.T) {
+	s, now := newTestStore(time.Hour)
+	s.Set(&Data{Step: 1}, 1)
+
+	*now = now.Add(50 * time.Minute)
+	s.Set(&Data{Step: 2}, 1)
+
+	*now = now.Add(50 * time.Minute)
+	data, ok := s.Read(1)
+	if !ok || data.Step != 2 {
+		t.Fatalf("Read = %+v, %v, want state of the last Set", data, ok)
+	}
+}
+
+func TestStorePurgesExpired(t *testing.T) {
+	s, now := newTestStore(time.Minute)
+	s.Set(&Data{}, 1)
+
+	*now = now.Add(purgeInterval)
+	s.Set(&Data{}, 2)
+
+	if _, ok := s.store[1]; ok {
+		t.Fatal("expired state was not purged on Set")
+	}
+	if _, ok := s.Read(2); !ok {
+		t.Fatal("fresh state was purged")
+	}
+}
+
+func TestStoreWithoutTTL(t *testing.T) {
+	s, now := newTestStore(0)
+	s.Set(&Data{}, 1)
+
+	*now = now.Add(365 * 24 * time.Hour)
+	if _, ok := s.Read(1); !ok {
+		t.Fatal("state without ttl expired")
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s, _ := newTestStore(time.Hour)
+	s.Set(&Data{}, 1)
+	s.Delete(1)
+
+	if _, ok := s.Read(1); ok {
+		t.Fatal("state is readable after Delete")
+	}
+}
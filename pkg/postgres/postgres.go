@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -24,7 +28,12 @@ func New(ctx context.Context, maxAttempts int, url string) (*Postgres, error) {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		pool, err := pgxpool.New(ctx, url)
+		config, err := newPoolConfig(url)
+		if err != nil {
+			return err
+		}
+
+		pool, err := pgxpool.NewWithConfig(ctx, config)
 		if err != nil {
 			return err
 		}
@@ -47,6 +56,41 @@ func New(ctx context.Context, maxAttempts int, url string) (*Postgres, error) {
 	return db, nil
 }
 
+// newPoolConfig - колонки timestamp хранят время без зоны, часть из них пишет бот через time.Now().Local(),
+// часть - база через now(). Чтобы это были одни часы, сессия работает в зоне бота,
+// а прочитанное время считается местным
+func newPoolConfig(url string) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if zone := localZone(); zone != "" {
+		config.ConnConfig.RuntimeParams["timezone"] = zone
+	}
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamp",
+			OID:   pgtype.TimestampOID,
+			Codec: &pgtype.TimestampCodec{ScanLocation: time.Local},
+		})
+		return nil
+	}
+
+	return config, nil
+}
+
+// localZone - имя зоны бота из TZ, например Europe/Moscow. Пустое - зона сервера базы
+func localZone() string {
+	if zone := strings.TrimPrefix(os.Getenv("TZ"), ":"); zone != "" {
+		return zone
+	}
+	if zone := time.Local.String(); zone != "Local" {
+		return zone
+	}
+	return ""
+}
+
 func DoWithTries(fn func() error, attemtps int, delay time.Duration) (err error) {
 	for attemtps > 0 {
 		if err = fn(); err != nil {
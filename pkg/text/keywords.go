@@ -0,0 +1,82 @@
+package text
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minWordLen - более короткие слова почти всегда предлоги и союзы
+const minWordLen = 4
+
+type Keyword struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+var stopWords = map[string]struct{}{
+	"этот": {}, "этого": {}, "этом": {}, "этих": {}, "этой": {}, "будет": {}, "будут": {}, "было": {},
+	"были": {}, "была": {}, "есть": {}, "если": {}, "когда": {}, "какой": {}, "какие": {},
+	"какая": {}, "какое": {}, "каким": {}, "каких": {}, "почему": {}, "зачем": {}, "сколько": {},
+	"также": {}, "тоже": {}, "только": {}, "чтобы": {}, "можно": {}, "нужно": {}, "надо": {},
+	"очень": {}, "более": {}, "менее": {}, "сейчас": {}, "теперь": {}, "всех": {}, "всем": {},
+	"свой": {}, "свои": {}, "своих": {}, "который": {}, "которые": {}, "которых": {}, "которая": {},
+	"него": {}, "вами": {}, "ваши": {}, "нами": {}, "наши": {}, "наша": {}, "здравствуйте": {},
+	"добрый": {}, "день": {}, "спасибо": {}, "пожалуйста": {}, "подскажите": {}, "скажите": {},
+	"вопрос": {}, "вопросы": {}, "через": {}, "после": {}, "перед": {}, "между": {}, "потому": {},
+	"поэтому": {}, "может": {}, "могут": {}, "будем": {}, "ожидать": {}, "здесь": {}, "году": {},
+	"года": {}, "годах": {},
+}
+
+// Words - разбивает текст на слова в нижнем регистре, отбрасывая пунктуацию и цифры
+func Words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	})
+}
+
+// IsStopWord - служебные и слишком короткие слова не несут смысла для статистики
+func IsStopWord(word string) bool {
+	if utf8.RuneCountInString(word) < minWordLen {
+		return true
+	}
+	_, ok := stopWords[word]
+	return ok
+}
+
+// TopKeywords - limit самых частых значимых слов в texts. Слово учитывается один раз на текст
+func TopKeywords(texts []string, limit int) []Keyword {
+	counts := make(map[string]int)
+	for _, t := range texts {
+		seen := make(map[string]struct{})
+		for _, word := range Words(t) {
+			word = strings.Trim(word, "-")
+			if IsStopWord(word) {
+				continue
+			}
+			if _, ok := seen[word]; ok {
+				continue
+			}
+			seen[word] = struct{}{}
+			counts[word]++
+		}
+	}
+
+	keywords := make([]Keyword, 0, len(counts))
+	for word, count := range counts {
+		keywords = append(keywords, Keyword{Word: word, Count: count})
+	}
+
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Count == keywords[j].Count {
+			return keywords[i].Word < keywords[j].Word
+		}
+		return keywords[i].Count > keywords[j].Count
+	})
+
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
@@ -0,0 +1,71 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	got := Words("Курс рубля: 100,5 — много? Рост-цен!")
+	want := []string{"курс", "рубля", "много", "рост-цен"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Words = %q, want %q", got, want)
+	}
+}
+
+func TestIsStopWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "курс", want: false},
+		{word: "инфляция", want: false},
+		{word: "как", want: true},
+		{word: "будет", want: true},
+		{word: "здравствуйте", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsStopWord(tt.word); got != tt.want {
+			t.Errorf("IsStopWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestTopKeywords(t *testing.T) {
+	texts := []string{
+		"Здравствуйте! Какой будет курс рубля, курс доллара?",
+		"Курс рубля упадет?",
+		"Что с инфляцией",
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []Keyword
+	}{
+		{
+			name:  "word is counted once per text",
+			limit: 2,
+			want:  []Keyword{{Word: "курс", Count: 2}, {Word: "рубля", Count: 2}},
+		},
+		{
+			name:  "equal counts are sorted by word",
+			limit: 10,
+			want: []Keyword{
+				{Word: "курс", Count: 2}, {Word: "рубля", Count: 2},
+				{Word: "доллара", Count: 1}, {Word: "инфляцией", Count: 1}, {Word: "упадет", Count: 1},
+			},
+		},
+		{name: "zero limit", limit: 0, want: []Keyword{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TopKeywords(texts, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("TopKeywords = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minStemLen - окончание не отрезается, если от слова останется меньше
+const minStemLen = 3
+
+// endings - окончания и суффиксы русских слов, длинные проверяются раньше коротких
+var endings = []string{
+	"ившись", "ывшись",
+	"ость", "ости", "иями",
+	"ями", "ами", "ией", "иям", "ием", "иях", "ого", "его", "ому", "ему", "ыми", "ими",
+	"ешь", "ишь", "ете", "ите", "ает", "яет", "ует", "ают", "яют", "уют",
+	"ала", "ила", "ыла", "ела", "али", "или", "ыли", "ели",
+	"ов", "ев", "ей", "ой", "ий", "ый", "ая", "яя", "ое", "ее", "ые", "ие", "ую", "юю",
+	"ом", "ем", "ам", "ям", "ах", "ях", "ть", "ет", "ит", "ут", "ют", "ат", "ят",
+	"ла", "ли", "ло", "ия", "ья", "ии", "ию", "ье",
+	"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+}
+
+// Stem - упрощенный стеммер: отрезает возвратную частицу и одно окончание,
+// чтобы разные формы слова ("инфляция", "инфляции", "инфляцией") совпадали
+func Stem(word string) string {
+	word = strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+
+	for _, suffix := range []string{"ся", "сь"} {
+		if trimmed, ok := trimSuffix(word, suffix); ok {
+			word = trimmed
+			break
+		}
+	}
+
+	for _, ending := range endings {
+		if trimmed, ok := trimSuffix(word, ending); ok {
+			return trimmed
+		}
+	}
+	return word
+}
+
+// Stems - основы всех слов текста в исходном порядке
+func Stems(s string) []string {
+	words := Words(s)
+	stems := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.Trim(word, "-"); word != "" {
+			stems = append(stems, Stem(word))
+		}
+	}
+	return stems
+}
+
+// ContainsPhrase - есть ли в stems подряд идущие основы слов phrase
+func ContainsPhrase(stems []string, phrase string) bool {
+	phraseStems := Stems(phrase)
+	if len(phraseStems) == 0 || len(phraseStems) > len(stems) {
+		return false
+	}
+
+	for i := 0; i+len(phraseStems) <= len(stems); i++ {
+		matched := true
+		for j, stem := range phraseStems {
+			if stems[i+j] != stem {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func trimSuffix(word, suffix string) (string, bool) {
+	if !strings.HasSuffix(word, suffix) {
+		return word, false
+	}
+
+	trimmed := strings.TrimSuffix(word, suffix)
+	if utf8.RuneCountInString(trimmed) < minStemLen {
+		return word, false
+	}
+	return trimmed, true
+}
@@ -0,0 +1,67 @@
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "инфляция", want: "инфляц"},
+		{word: "инфляции", want: "инфляц"},
+		{word: "инфляцией", want: "инфляц"},
+		{word: "Инфляцию", want: "инфляц"},
+		{word: "ставка", want: "ставк"},
+		{word: "ставкой", want: "ставк"},
+		{word: "ключевая", want: "ключев"},
+		{word: "ключевой", want: "ключев"},
+		{word: "ростом", want: "рост"},
+		{word: "ёлка", want: "елк"},
+		{word: "учиться", want: "учи"},
+		// от слова осталось бы меньше minStemLen
+		{word: "кот", want: "кот"},
+		{word: "цены", want: "цен"},
+		{word: "мы", want: "мы"},
+	}
+
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStems(t *testing.T) {
+	got := Stems("Что будет с ключевой ставкой? Рост-цен, 2024 -")
+	want := []string{"что", "буд", "с", "ключев", "ставк", "рост-цен"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stems = %q, want %q", got, want)
+	}
+}
+
+func TestContainsPhrase(t *testing.T) {
+	stems := Stems("Когда снизят ключевую ставку ЦБ?")
+
+	tests := []struct {
+		phrase string
+		want   bool
+	}{
+		{phrase: "ключевая ставка", want: true},
+		{phrase: "ключевой ставкой", want: true},
+		{phrase: "ставка", want: true},
+		{phrase: "ставка ключевая", want: false},
+		{phrase: "ключевая ставка ЦБ России", want: false},
+		{phrase: "", want: false},
+		{phrase: "?!", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := ContainsPhrase(stems, tt.phrase); got != tt.want {
+			t.Errorf("ContainsPhrase(%q) = %v, want %v", tt.phrase, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxDataLen - ограничение Telegram на длину callback data в байтах
+	MaxDataLen = 64
+
+	separator = ":"
+	// payloadPrefix - callback data хранится на сервере, в кнопке только ключ
+	payloadPrefix = "~"
+	payloadKeyLen = 16
+)
+
+var escaper = strings.NewReplacer("%", "%25", separator, "%3A")
+
+// Data - callback data кнопки: точное имя действия и параметры, например q_check:1234
+type Data struct {
+	Action string
+	Args   []string
+}
+
+// Encode - callback data действия с параметрами. Если она длиннее MaxDataLen,
+// в кнопку попадает ключ, а сами данные сохраняются в Payloads
+func Encode(action string, args ...any) string {
+	var sb strings.Builder
+	sb.WriteString(action)
+	for _, arg := range args {
+		sb.WriteString(separator)
+		sb.WriteString(escaper.Replace(fmt.Sprint(arg)))
+	}
+
+	data := sb.String()
+	if len(data) <= MaxDataLen {
+		return data
+	}
+
+	sum := sha256.Sum256([]byte(data))
+	key := payloadPrefix + base64.RawURLEncoding.EncodeToString(sum[:])[:payloadKeyLen]
+	Payloads.Save(key, data)
+
+	return key
+}
+
+// Parse - разбирает callback data, сохраненную на сервере, достает из Payloads.
+// ErrButtonExpired, если ее там уже нет
+func Parse(data string) (Data, error) {
+	if strings.HasPrefix(data, payloadPrefix) {
+		payload, ok := Payloads.Load(data)
+		if !ok {
+			return Data{}, customErr.ErrButtonExpired
+		}
+		data = payload
+	}
+
+	parts := strings.Split(data, separator)
+	args := parts[1:]
+	for i, arg := range args {
+		unescaped, err := url.PathUnescape(arg)
+		if err != nil {
+			return Data{}, customErr.ErrInvalidRequest
+		}
+		args[i] = unescaped
+	}
+
+	return Data{Action: parts[0], Args: args}, nil
+}
+
+// String - параметр i, ErrInvalidRequest, если его нет
+func (d Data) String(i int) (string, error) {
+	if i < 0 || i >= len(d.Args) {
+		return "", customErr.ErrInvalidRequest
+	}
+	return d.Args[i], nil
+}
+
+// Int - числовой параметр i
+func (d Data) Int(i int) (int, error) {
+	arg, err := d.String(i)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, customErr.ErrInvalidRequest
+	}
+	return value, nil
+}
+
+// Int64 - числовой параметр i, например id пользователя
+func (d Data) Int64(i int) (int64, error) {
+	arg, err := d.String(i)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, customErr.ErrInvalidRequest
+	}
+	return value, nil
+}
+
+type contextKey struct{}
+
+// WithData - разобранная callback data передается обработчику кнопки через ctx
+func WithData(ctx context.Context, data Data) context.Context {
+	return context.WithValue(ctx, contextKey{}, data)
+}
+
+// FromContext - callback data нажатой кнопки
+func FromContext(ctx context.Context) Data {
+	data, _ := ctx.Value(contextKey{}).(Data)
+	return data
+}
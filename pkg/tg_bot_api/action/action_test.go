@@ -0,0 +1,144 @@
+package action
+
+import (
+	"errors"
+	customErr "github.com/Enthreeka/tg-question-bot/pkg/bot_error"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func withMemoryPayloads(t *testing.T, limit int) {
+	t.Helper()
+
+	previous := Payloads
+	Payloads = NewMemoryPayloads(limit)
+	t.Cleanup(func() { Payloads = previous })
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		args   []any
+		want   string
+	}{
+		{name: "no args", action: "q_list", want: "q_list"},
+		{name: "int args", action: "q_handto", args: []any{12, int64(-100500)}, want: "q_handto:12:-100500"},
+		{name: "separator is escaped", action: "tag", args: []any{"a:b"}, want: "tag:a%3Ab"},
+		{name: "percent is escaped", action: "tag", args: []any{"100%"}, want: "tag:100%25"},
+		{name: "escaped percent is not unescaped twice", action: "tag", args: []any{"%3A"}, want: "tag:%253A"},
+		{name: "exactly MaxDataLen", action: "a", args: []any{strings.Repeat("x", MaxDataLen-2)},
+			want: "a:" + strings.Repeat("x", MaxDataLen-2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withMemoryPayloads(t, 10)
+
+			if got := Encode(tt.action, tt.args...); got != tt.want {
+				t.Fatalf("Encode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeLongData(t *testing.T) {
+	withMemoryPayloads(t, 10)
+
+	arg := strings.Repeat("x", MaxDataLen-1)
+	key := Encode("a", arg)
+
+	if !strings.HasPrefix(key, payloadPrefix) || len(key) != len(payloadPrefix)+payloadKeyLen {
+		t.Fatalf("Encode of %d bytes = %q, want payload key", len("a:"+arg), key)
+	}
+	if len(key) > MaxDataLen {
+		t.Fatalf("payload key is %d bytes, longer than MaxDataLen", len(key))
+	}
+	if again := Encode("a", arg); again != key {
+		t.Fatalf("Encode of the same data = %q, want %q", again, key)
+	}
+}
+
+func TestParse(t *testing.T) {
+	long := strings.Repeat("вопрос", 20)
+
+	tests := []struct {
+		name   string
+		action string
+		args   []any
+		want   Data
+	}{
+		{name: "no args", action: "q_list", want: Data{Action: "q_list", Args: []string{}}},
+		{name: "args", action: "q_handto", args: []any{12, 100500}, want: Data{Action: "q_handto", Args: []string{"12", "100500"}}},
+		{name: "escaped args", action: "tag", args: []any{"a:b", "100%", "%3A"},
+			want: Data{Action: "tag", Args: []string{"a:b", "100%", "%3A"}}},
+		{name: "stored payload", action: "search", args: []any{long, 3}, want: Data{Action: "search", Args: []string{long, "3"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withMemoryPayloads(t, 10)
+
+			got, err := Parse(Encode(tt.action, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(Encode) = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{name: "unknown payload key", data: payloadPrefix + "missing", want: customErr.ErrButtonExpired},
+		{name: "broken escape", data: "tag:%zz", want: customErr.ErrInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withMemoryPayloads(t, 10)
+
+			if _, err := Parse(tt.data); !errors.Is(err, tt.want) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.data, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryPayloadsEvictsOldest(t *testing.T) {
+	m := NewMemoryPayloads(2)
+	m.Save("a", "1")
+	m.Save("b", "2")
+	m.Save("a", "ignored")
+	m.Save("c", "3")
+
+	if _, ok := m.Load("a"); ok {
+		t.Fatal("oldest payload was not evicted")
+	}
+	for key, want := range map[string]string{"b": "2", "c": "3"} {
+		if got, ok := m.Load(key); !ok || got != want {
+			t.Fatalf("Load(%q) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+}
+
+func TestDataArgs(t *testing.T) {
+	data := Data{Action: "q", Args: []string{"12", "abc"}}
+
+	if got, err := data.Int(0); err != nil || got != 12 {
+		t.Fatalf("Int(0) = %d, %v, want 12", got, err)
+	}
+	if _, err := data.Int(1); !errors.Is(err, customErr.ErrInvalidRequest) {
+		t.Fatalf("Int of non-number error = %v, want ErrInvalidRequest", err)
+	}
+	if _, err := data.String(2); !errors.Is(err, customErr.ErrInvalidRequest) {
+		t.Fatalf("String out of range error = %v, want ErrInvalidRequest", err)
+	}
+}
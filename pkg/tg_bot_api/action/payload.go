@@ -0,0 +1,58 @@
+package action
+
+import (
+	"sync"
+)
+
+// payloadLimit - сколько длинных callback data хранится, самые старые вытесняются
+const payloadLimit = 10000
+
+// PayloadStore - хранилище callback data, не поместившейся в кнопку
+type PayloadStore interface {
+	Save(key string, data string)
+	Load(key string) (string, bool)
+}
+
+// Payloads - хранилище, которое используют Encode и Parse. По умолчанию данные хранятся в памяти
+// и теряются при перезапуске, кнопки с ними отвечают ErrButtonExpired. При хранилище состояний
+// в Redis или Postgres бот заменяет его на хранилище в том же бэкенде
+var Payloads PayloadStore = NewMemoryPayloads(payloadLimit)
+
+type MemoryPayloads struct {
+	limit int
+	data  map[string]string
+	order []string
+
+	mu sync.RWMutex
+}
+
+func NewMemoryPayloads(limit int) *MemoryPayloads {
+	return &MemoryPayloads{
+		limit: limit,
+		data:  make(map[string]string),
+	}
+}
+
+func (m *MemoryPayloads) Save(key string, data string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.data[key]; ok {
+		return
+	}
+
+	if len(m.order) >= m.limit {
+		delete(m.data, m.order[0])
+		m.order = m.order[1:]
+	}
+	m.data[key] = data
+	m.order = append(m.order, key)
+}
+
+func (m *MemoryPayloads) Load(key string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.data[key]
+	return data, ok
+}
@@ -0,0 +1,109 @@
+package tg_bot_api
+
+import (
+	"context"
+	"encoding/json"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Forum - методы Bot API для тем форума, которых нет в telegram-bot-api v5.5.1
+type Forum interface {
+	CreateForumTopic(ctx context.Context, chatID int64, name string) (int, error)
+	CloseForumTopic(ctx context.Context, chatID int64, threadID int) error
+	ReopenForumTopic(ctx context.Context, chatID int64, threadID int) error
+	// CopyMessage - копирует сообщение в тему threadID, threadID = 0 означает чат без тем
+	CopyMessage(ctx context.Context, chatID int64, threadID int, fromChatID int64, messageID int) (int, error)
+	SendTopicMessage(ctx context.Context, chatID int64, threadID int, text string) (int, error)
+}
+
+func (t *TelegramMsg) CreateForumTopic(ctx context.Context, chatID int64, name string) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonEmpty("name", name)
+
+	resp, err := t.request(ctx, chatID, "createForumTopic", params)
+	if err != nil {
+		t.log.Error("failed to create forum topic: %v", err)
+		return 0, err
+	}
+
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &topic); err != nil {
+		return 0, err
+	}
+
+	return topic.MessageThreadID, nil
+}
+
+func (t *TelegramMsg) CloseForumTopic(ctx context.Context, chatID int64, threadID int) error {
+	return t.topicRequest(ctx, "closeForumTopic", chatID, threadID)
+}
+
+func (t *TelegramMsg) ReopenForumTopic(ctx context.Context, chatID int64, threadID int) error {
+	return t.topicRequest(ctx, "reopenForumTopic", chatID, threadID)
+}
+
+func (t *TelegramMsg) CopyMessage(ctx context.Context, chatID int64, threadID int, fromChatID int64, messageID int) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonZero64("from_chat_id", fromChatID)
+	params.AddNonZero("message_id", messageID)
+
+	resp, err := t.request(ctx, chatID, "copyMessage", params)
+	if err != nil {
+		t.log.Error("failed to copy message: %v", err)
+		return 0, err
+	}
+
+	var messageIDResult tgbotapi.MessageID
+	if err := json.Unmarshal(resp.Result, &messageIDResult); err != nil {
+		return 0, err
+	}
+
+	return messageIDResult.MessageID, nil
+}
+
+func (t *TelegramMsg) SendTopicMessage(ctx context.Context, chatID int64, threadID int, text string) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonEmpty("text", text)
+	params.AddNonEmpty("parse_mode", tgbotapi.ModeHTML)
+
+	resp, err := t.request(ctx, chatID, "sendMessage", params)
+	if err != nil {
+		t.log.Error("failed to send topic message: %v", err)
+		return 0, err
+	}
+
+	var message tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &message); err != nil {
+		return 0, err
+	}
+
+	return message.MessageID, nil
+}
+
+func (t *TelegramMsg) topicRequest(ctx context.Context, endpoint string, chatID int64, threadID int) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+
+	if _, err := t.request(ctx, chatID, endpoint, params); err != nil {
+		t.log.Error("failed to %s: %v", endpoint, err)
+		return err
+	}
+	return nil
+}
+
+func (t *TelegramMsg) request(ctx context.Context, chatID int64, endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	var resp *tgbotapi.APIResponse
+	err := t.send(ctx, chatID, func() (err error) {
+		resp, err = t.bot.MakeRequest(endpoint, params)
+		return err
+	})
+	return resp, err
+}
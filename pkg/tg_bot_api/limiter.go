@@ -0,0 +1,177 @@
+package tg_bot_api
+
+import (
+	"context"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Ограничения Bot API: около 30 сообщений в секунду всего, не больше одного в секунду
+// в личный чат и 20 в минуту в группу
+const (
+	GlobalRate  = 30
+	GlobalBurst = 30
+
+	PrivateChatRate  = 1
+	PrivateChatBurst = 3
+
+	GroupChatRate  = 20.0 / 60
+	GroupChatBurst = 3
+)
+
+const (
+	// maxRetries - сколько раз повторять запрос после 429 Too Many Requests
+	maxRetries = 3
+	// idleChatLimit - после этого числа чатов из памяти удаляются лимиты неактивных
+	idleChatLimit = 1000
+)
+
+// bucket - token bucket. Токены могут уйти в минус: отрицательный остаток означает очередь ожидающих
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate, burst float64) *bucket {
+	return &bucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// reserve - занимает токен и возвращает, сколько ждать до его появления
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Limiter - общий и поличатовый лимит запросов к Bot API
+type Limiter struct {
+	global *bucket
+	chats  map[int64]*bucket
+	now    func() time.Time
+
+	mu sync.Mutex
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		global: newBucket(GlobalRate, GlobalBurst),
+		chats:  make(map[int64]*bucket),
+		now:    time.Now,
+	}
+}
+
+// Wait - блокирует, пока запрос в chatID не уложится в оба лимита.
+// chatID = 0 - запрос не адресован чату, например меню команд, учитывается только общий лимит
+func (l *Limiter) Wait(ctx context.Context, chatID int64) error {
+	delay := l.reserve(chatID)
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve - занимает место в лимитах chatID и возвращает, сколько ждать до отправки
+func (l *Limiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if chatID == 0 {
+		return l.global.reserve(now)
+	}
+
+	chat, ok := l.chats[chatID]
+	if !ok {
+		l.forgetIdle(now)
+
+		chat = newBucket(PrivateChatRate, PrivateChatBurst)
+		if chatID < 0 {
+			chat = newBucket(GroupChatRate, GroupChatBurst)
+		}
+		chat.last = now
+		l.chats[chatID] = chat
+	}
+	return max(chat.reserve(now), l.global.reserve(now))
+}
+
+// forgetIdle - лимит чата, который успел полностью восстановиться, можно создать заново
+func (l *Limiter) forgetIdle(now time.Time) {
+	if len(l.chats) < idleChatLimit {
+		return
+	}
+	for chatID, chat := range l.chats {
+		chat.refill(now)
+		if chat.tokens >= chat.burst {
+			delete(l.chats, chatID)
+		}
+	}
+}
+
+// retryAfter - время, через которое Telegram разрешает повторить запрос после 429
+func retryAfter(err error) time.Duration {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return time.Duration(apiErr.RetryAfter) * time.Second
+	}
+	return 0
+}
+
+// IsPermanent - Telegram отклонил запрос и повтор не поможет: бот заблокирован, чат не найден,
+// некорректный текст. 429 к таким ошибкам не относится
+func IsPermanent(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusBadRequest || apiErr.Code == http.StatusForbidden
+}
+
+// send - выполняет request с учетом лимитов chatID и повторяет его после 429.
+// Ожидание прерывается отменой ctx
+func (t *TelegramMsg) send(ctx context.Context, chatID int64, request func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(ctx, chatID); err != nil {
+			return err
+		}
+
+		err := request()
+		wait := retryAfter(err)
+		if wait == 0 || attempt == maxRetries {
+			return err
+		}
+
+		t.log.Info("Telegram rate limit for chat %d, retry in %s", chatID, wait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.after(wait):
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package tg_bot_api
+
+import (
+	"context"
+	"errors"
+	"github.com/Enthreeka/tg-question-bot/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestBucketReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst float64
+		// elapsed - время от начала теста до каждого reserve
+		elapsed []time.Duration
+		want    []time.Duration
+	}{
+		{
+			name:    "burst without delay",
+			rate:    1,
+			burst:   3,
+			elapsed: []time.Duration{0, 0, 0},
+			want:    []time.Duration{0, 0, 0},
+		},
+		{
+			name:    "queue after burst",
+			rate:    1,
+			burst:   2,
+			elapsed: []time.Duration{0, 0, 0, 0},
+			want:    []time.Duration{0, 0, time.Second, 2 * time.Second},
+		},
+		{
+			name:    "refill after pause",
+			rate:    1,
+			burst:   1,
+			elapsed: []time.Duration{0, 0, 3 * time.Second},
+			want:    []time.Duration{0, time.Second, 0},
+		},
+		{
+			name:    "refill is capped by burst",
+			rate:    1,
+			burst:   1,
+			elapsed: []time.Duration{0, time.Hour, time.Hour},
+			want:    []time.Duration{0, 0, time.Second},
+		},
+		{
+			name:    "group chat rate",
+			rate:    GroupChatRate,
+			burst:   1,
+			elapsed: []time.Duration{0, 0},
+			want:    []time.Duration{0, 3 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bucket{rate: tt.rate, burst: tt.burst, tokens: tt.burst, last: testNow}
+
+			got := make([]time.Duration, len(tt.elapsed))
+			for i, elapsed := range tt.elapsed {
+				got[i] = b.reserve(testNow.Add(elapsed)).Round(time.Millisecond)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("reserve delays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestLimiter() *Limiter {
+	l := NewLimiter()
+	l.global.last = testNow
+	l.now = func() time.Time { return testNow }
+	return l
+}
+
+func TestLimiterReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		chats []int64
+		// want - задержка последнего запроса
+		want time.Duration
+	}{
+		{name: "private chat burst", chats: []int64{1, 1, 1}, want: 0},
+		{name: "private chat over burst", chats: []int64{1, 1, 1, 1}, want: time.Second},
+		{name: "group chat over burst", chats: []int64{-1, -1, -1, -1}, want: 3 * time.Second},
+		{name: "chats are limited separately", chats: []int64{1, 1, 1, 2}, want: 0},
+		{name: "request without chat uses only global limit", chats: []int64{0, 0, 0, 0, 0}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLimiter()
+
+			var got time.Duration
+			for _, chatID := range tt.chats {
+				got = l.reserve(chatID)
+			}
+
+			if got.Round(time.Millisecond) != tt.want {
+				t.Fatalf("reserve delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterGlobalLimit(t *testing.T) {
+	l := newTestLimiter()
+
+	for chatID := int64(1); chatID <= GlobalBurst; chatID++ {
+		if delay := l.reserve(chatID); delay != 0 {
+			t.Fatalf("request %d within global burst delayed by %v", chatID, delay)
+		}
+	}
+
+	want := time.Second / GlobalRate
+	if delay := l.reserve(GlobalBurst + 1); delay.Round(time.Millisecond) != want.Round(time.Millisecond) {
+		t.Fatalf("request over global burst delayed by %v, want %v", delay, want)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "no error", err: nil, want: 0},
+		{name: "network error", err: errors.New("connection reset"), want: 0},
+		{name: "bad request", err: &tgbotapi.Error{Code: http.StatusBadRequest, Message: "chat not found"}, want: 0},
+		{name: "too many requests", err: &tgbotapi.Error{Code: http.StatusTooManyRequests,
+			ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}}, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.err); got != tt.want {
+				t.Fatalf("retryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func tooManyRequests(seconds int) error {
+	return &tgbotapi.Error{Code: http.StatusTooManyRequests, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: seconds}}
+}
+
+// newTestMsg - запросы без чата не задерживаются лимитами, паузы после 429 не ждутся, а записываются в waits
+func newTestMsg(waits *[]time.Duration) *TelegramMsg {
+	l := newTestLimiter()
+	l.global.burst, l.global.tokens = 1000, 1000
+
+	return &TelegramMsg{
+		log:     logger.New(),
+		limiter: l,
+		after: func(d time.Duration) <-chan time.Time {
+			*waits = append(*waits, d)
+			ch := make(chan time.Time, 1)
+			ch <- testNow
+			return ch
+		},
+	}
+}
+
+func TestSendRetry(t *testing.T) {
+	permanent := &tgbotapi.Error{Code: http.StatusForbidden, Message: "bot was blocked by the user"}
+
+	tests := []struct {
+		name      string
+		responses []error
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   error
+	}{
+		{name: "success", responses: []error{nil}, wantCalls: 1},
+		{name: "permanent error is not retried", responses: []error{permanent}, wantCalls: 1, wantErr: permanent},
+		{
+			name:      "retry after 429",
+			responses: []error{tooManyRequests(2), nil},
+			wantCalls: 2,
+			wantWaits: []time.Duration{2 * time.Second},
+		},
+		{
+			name:      "gives up after maxRetries",
+			responses: []error{tooManyRequests(1), tooManyRequests(1), tooManyRequests(1), tooManyRequests(7)},
+			wantCalls: maxRetries + 1,
+			wantWaits: []time.Duration{time.Second, time.Second, time.Second},
+			wantErr:   tooManyRequests(7),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waits []time.Duration
+			msg := newTestMsg(&waits)
+
+			calls := 0
+			err := msg.send(context.Background(), 0, func() error {
+				err := tt.responses[calls]
+				calls++
+				return err
+			})
+
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(waits, tt.wantWaits) {
+				t.Fatalf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	msg := newTestMsg(new([]time.Duration))
+	msg.after = func(time.Duration) <-chan time.Time {
+		cancel()
+		return make(chan time.Time)
+	}
+
+	calls := 0
+	err := msg.send(ctx, 0, func() error {
+		calls++
+		return tooManyRequests(30)
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
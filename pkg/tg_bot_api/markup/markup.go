@@ -1,24 +1,61 @@
 package markup
 
 import (
+	"fmt"
+	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/action"
 	"github.com/Enthreeka/tg-question-bot/pkg/tg_bot_api/button"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
 )
 
+// PermissionButton - кнопка, которая показывается только при наличии права Permission.
+// Пустое Permission означает, что кнопка видна всем
+type PermissionButton struct {
+	Permission string
+	Button     tgbotapi.InlineKeyboardButton
+}
+
+type PermissionKeyboard [][]PermissionButton
+
+// For - собирает клавиатуру из кнопок, разрешенных allowed. Пустые ряды отбрасываются
+func (k PermissionKeyboard) For(allowed func(permission string) bool) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(k))
+	for _, permissionRow := range k {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, b := range permissionRow {
+			if b.Permission == "" || allowed(b.Permission) {
+				row = append(row, b.Button)
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
 var (
-	StartMenu = tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Скачать вопросы", "bot_setting")),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Управление пользователями", "user_setting")),
-	)
+	StartMenu = PermissionKeyboard{
+		{{Permission: "question.export", Button: tgbotapi.NewInlineKeyboardButtonData("Скачать вопросы", "bot_setting")}},
+		{{Permission: "role.manage", Button: tgbotapi.NewInlineKeyboardButtonData("Управление пользователями", "user_setting")}},
+		{{Permission: "question.read", Button: tgbotapi.NewInlineKeyboardButtonData("Последние вопросы", action.Encode("q_list", 0))}},
+		{{Permission: "question.answer", Button: tgbotapi.NewInlineKeyboardButtonData("Мои вопросы", "my_questions")}},
+		{{Permission: "question.read", Button: tgbotapi.NewInlineKeyboardButtonData("Поиск по вопросам", "search_start")}},
+		{{Permission: "question.read", Button: tgbotapi.NewInlineKeyboardButtonData("Скорость ответов", action.Encode("sla_stats", "day", 0))}},
+		{{Permission: "tag.manage", Button: tgbotapi.NewInlineKeyboardButtonData("Теги", "tag_setting")}},
+		{{Permission: "faq.manage", Button: tgbotapi.NewInlineKeyboardButtonData("FAQ", "faq_setting")}},
+		{{Permission: "panel.access", Button: tgbotapi.NewInlineKeyboardButtonData("Дайджест", "digest_setting")}},
+		{{Permission: "outbox.manage", Button: tgbotapi.NewInlineKeyboardButtonData("Доставка сообщений", "outbox_list")}},
+		{{Permission: "audit.read", Button: tgbotapi.NewInlineKeyboardButtonData("Журнал действий", "audit_log")}},
+	}
 
 	UserSetting = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Назначить роль администратора", "admin_set_role"),
+			tgbotapi.NewInlineKeyboardButtonData("Назначить роль", "admin_set_role"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Отозвать роль администратора", "admin_delete_role"),
+			tgbotapi.NewInlineKeyboardButtonData("Отозвать роль", "admin_delete_role"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("Посмотреть список администраторов", "admin_look_up"),
@@ -41,4 +78,426 @@ var (
 	)
 
 	MainMenu = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	TagBack = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Вернуться к тегам", "tag_setting")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	TagRuleBack = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Вернуться к правилам", "rule_list")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	FaqBack = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Вернуться к FAQ", "faq_setting")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
 )
+
+// RolePick - roles содержит пары (роль, название роли)
+func RolePick(roles [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(roles)+1)
+	for _, role := range roles {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(role[1], action.Encode("role_pick", role[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Вернуться назад", "user_setting")))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func AuditPage(page, pages int) tgbotapi.InlineKeyboardMarkup {
+	var navigation []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("⬅️", action.Encode("audit_page", page-1)))
+	}
+	if page < pages-1 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("➡️", action.Encode("audit_page", page+1)))
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, 3)
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Выгрузить журнал", "audit_export")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+type QuestionCardState struct {
+	ID        int
+	Status    string
+	Assigned  bool
+	Published bool
+	// ClusterID - основной вопрос, если вопрос является дубликатом
+	ClusterID int
+}
+
+// QuestionCard - кнопки карточки вопроса в зависимости от его состояния
+func QuestionCard(state QuestionCardState) tgbotapi.InlineKeyboardMarkup {
+	var (
+		rows       [][]tgbotapi.InlineKeyboardButton
+		questionID = state.ID
+		status     = state.Status
+	)
+
+	isOpen := status == "new" || status == "checked"
+	if isOpen {
+		var first []tgbotapi.InlineKeyboardButton
+		if status == "new" {
+			first = append(first, tgbotapi.NewInlineKeyboardButtonData("Проверено", action.Encode("q_check", questionID)))
+		}
+		first = append(first, tgbotapi.NewInlineKeyboardButtonData("Ответить", action.Encode("q_answer", questionID)))
+		rows = append(rows, first)
+
+		claim := tgbotapi.NewInlineKeyboardButtonData("Взять в работу", action.Encode("q_claim", questionID))
+		if state.Assigned {
+			claim = tgbotapi.NewInlineKeyboardButtonData("Снять с себя", action.Encode("q_release", questionID))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			claim,
+			tgbotapi.NewInlineKeyboardButtonData("Передать", action.Encode("q_delegate", questionID)),
+		))
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Отклонить", action.Encode("q_reject", questionID)),
+			tgbotapi.NewInlineKeyboardButtonData("Забанить", action.Encode("q_ban", questionID)),
+		))
+	} else {
+		var row []tgbotapi.InlineKeyboardButton
+		if status == "answered" && !state.Published {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData("Опубликовано", action.Encode("q_publish", questionID)))
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("Забанить", action.Encode("q_ban", questionID)))
+		rows = append(rows, row)
+	}
+	if state.ClusterID != 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Основной вопрос", action.Encode("q_card", state.ClusterID)),
+			tgbotapi.NewInlineKeyboardButtonData("Отделить", action.Encode("q_detach", questionID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Теги", action.Encode("q_tags", questionID)),
+		tgbotapi.NewInlineKeyboardButtonData("Похожие", action.Encode("q_similar", questionID)),
+		tgbotapi.NewInlineKeyboardButtonData("Диалог", action.Encode("q_relay", questionID)),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// QuestionDelegate - admins содержит пары (id администратора, подпись)
+func QuestionDelegate(questionID int, admins [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(admins)+1)
+	for _, admin := range admins {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(admin[1], action.Encode("q_handto", questionID, admin[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Вернуться к вопросу", action.Encode("q_card", questionID))))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func QuestionOpen(questionID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Открыть вопрос", action.Encode("q_card", questionID))))
+}
+
+// QuestionList - questions содержит пары (id вопроса, подпись)
+func QuestionList(questions [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(questions)+1)
+	for _, question := range questions {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(question[1], action.Encode("q_card", question[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func DigestSetting(daily, weekly bool) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleTitle("Ежедневный", daily), action.Encode("digest_toggle", "daily"))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleTitle("Еженедельный", weekly), action.Encode("digest_toggle", "weekly"))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Показать за последние сутки", "digest_preview")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+}
+
+func toggleTitle(title string, enabled bool) string {
+	if enabled {
+		return "✅ " + title
+	}
+	return "❌ " + title
+}
+
+// SLAStats - tags содержит пары (id тега, название), tagID = 0 - без фильтра
+func SLAStats(period string, tagID int, tags [][2]string) tgbotapi.InlineKeyboardMarkup {
+	periods := [][2]string{{"day", "Сутки"}, {"week", "Неделя"}, {"month", "Месяц"}}
+
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(periods))
+	for _, p := range periods {
+		title := p[1]
+		if p[0] == period {
+			title = selectedTitle(title)
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(title, action.Encode("sla_stats", p[0], tagID)))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{row}
+	rows = append(rows, tagFilterRows(tagID, tags, func(id string) string {
+		return action.Encode("sla_stats", period, id)
+	})...)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func selectedTitle(title string) string {
+	return "• " + title + " •"
+}
+
+const tagFilterRowSize = 3
+
+// tagFilterRows - ряды кнопок фильтра по тегу, первая кнопка "Все" снимает фильтр
+func tagFilterRows(tagID int, tags [][2]string, data func(id string) string) [][]tgbotapi.InlineKeyboardButton {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	current := fmt.Sprint(tagID)
+	all := append([][2]string{{"0", "Все"}}, tags...)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(all); i += tagFilterRowSize {
+		end := min(i+tagFilterRowSize, len(all))
+
+		row := make([]tgbotapi.InlineKeyboardButton, 0, end-i)
+		for _, tag := range all[i:end] {
+			title := tag[1]
+			if tag[0] != "0" {
+				title = "#" + title
+			}
+			if tag[0] == current {
+				title = selectedTitle(title)
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(title, data(tag[0])))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// QuestionFilterList - вопросы с фильтром по тегу, popular - сортировка по числу голосов
+func QuestionFilterList(tagID int, popular bool, tags [][2]string, questions [][2]string) tgbotapi.InlineKeyboardMarkup {
+	latestTitle, popularTitle, list := selectedTitle("Новые"), "Популярные", "q_list"
+	if popular {
+		latestTitle, popularTitle, list = "Новые", selectedTitle("Популярные"), "q_top"
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(latestTitle, action.Encode("q_list", tagID)),
+		tgbotapi.NewInlineKeyboardButtonData(popularTitle, action.Encode("q_top", tagID)),
+	)}
+	rows = append(rows, tagFilterRows(tagID, tags, func(id string) string {
+		return action.Encode(list, id)
+	})...)
+	for _, question := range questions {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(question[1], action.Encode("q_card", question[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// QuestionExport - выбор тега для выгрузки вопросов
+func QuestionExport(tags [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tags)+2)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Все вопросы", action.Encode("q_export", 0))))
+	for _, tag := range tags {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("#"+tag[1], action.Encode("q_export", tag[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// QuestionTags - отметка тегов вопроса, selected - id отмеченных тегов
+func QuestionTags(questionID int, tags [][2]string, selected map[string]bool) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tags)+1)
+	for _, tag := range tags {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleTitle("#"+tag[1], selected[tag[0]]),
+				action.Encode("qtag", questionID, tag[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Вернуться к вопросу", action.Encode("q_card", questionID))))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// TagSetting - словарь тегов с кнопками удаления
+func TagSetting(tags [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tags)+2)
+	for _, tag := range tags {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 #"+tag[1], action.Encode("tag_delete", tag[0]))))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Добавить тег", "tag_create")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Правила разметки", "rule_list")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// TagRules - rules содержит пары (id правила, подпись)
+func TagRules(rules [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(rules)+2)
+	for _, rule := range rules {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 "+rule[1], action.Encode("rule_delete", rule[0]))))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Добавить правило", "rule_create")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Вернуться к тегам", "tag_setting")),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// FaqSetting - faqs содержит пары (id записи FAQ, подпись)
+func FaqSetting(faqs [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(faqs)+2)
+	for _, faq := range faqs {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 "+faq[1], action.Encode("faq_delete", faq[0]))))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Добавить ответ", "faq_create")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// FaqSuggestion - клавиатура под подсказкой, которую получает автор вопроса
+func FaqSuggestion(suggestionID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Спасибо, это помогло", action.Encode("faq_helped", suggestionID))),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Всё равно отправить вопрос", action.Encode("faq_send", suggestionID))),
+	)
+}
+
+// QuestionSimilar - similar содержит пары (id похожего вопроса, подпись)
+func QuestionSimilar(questionID int, similar [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(similar)+1)
+	for _, s := range similar {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(s[1], action.Encode("q_merge", questionID, s[0]))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Вернуться к вопросу", action.Encode("q_card", questionID))))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// SearchPage - кнопки найденных вопросов и навигация по страницам, запрос передается в кнопках навигации
+func SearchPage(questionIDs []string, query string, page, pages int) tgbotapi.InlineKeyboardMarkup {
+	results := make([]tgbotapi.InlineKeyboardButton, 0, len(questionIDs))
+	for _, id := range questionIDs {
+		results = append(results, tgbotapi.NewInlineKeyboardButtonData("#"+id, action.Encode("q_card", id)))
+	}
+
+	var navigation []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("⬅️", action.Encode("search_page", page-1, query)))
+	}
+	if page < pages-1 {
+		navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData("➡️", action.Encode("search_page", page+1, query)))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{results}
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button.MainMenuButton))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// VoteBallot - кнопки голосования за вопросы, подписанные номерами по порядку
+func VoteBallot(questionIDs []string) tgbotapi.InlineKeyboardMarkup {
+	votes := make([]tgbotapi.InlineKeyboardButton, 0, len(questionIDs))
+	for i, id := range questionIDs {
+		votes = append(votes, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👍 %d", i+1), action.Encode("vote_up", id)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		votes,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Показать другие", "vote_more")),
+	)
+}
+
+// MarkVoted - отмечает в бюллетене кнопку с данными data как нажатую
+func MarkVoted(ballot tgbotapi.InlineKeyboardMarkup, data string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(ballot.InlineKeyboard))
+	for _, row := range ballot.InlineKeyboard {
+		marked := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, b := range row {
+			if b.CallbackData != nil && *b.CallbackData == data {
+				b.Text = strings.Replace(b.Text, "👍", "✅", 1)
+			}
+			marked = append(marked, b)
+		}
+		rows = append(rows, marked)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// OutboxDead - messages содержит пары (id недоставленного сообщения, подпись)
+func OutboxDead(messages [][2]string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(messages)+3)
+	for _, message := range messages {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔁 "+message[1], action.Encode("outbox_retry", message[0]))))
+	}
+	if len(messages) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Повторить все", "outbox_requeue")))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Обновить", "outbox_list")),
+		tgbotapi.NewInlineKeyboardRow(button.MainMenuButton),
+	)
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// DialogPrompt - кнопки под приглашением к вводу, «Назад» есть начиная со второго шага
+func DialogPrompt(canGoBack bool) tgbotapi.InlineKeyboardMarkup {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, 2)
+	if canGoBack {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "dialog_back"))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData("Отмена", "dialog_cancel"))
+
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
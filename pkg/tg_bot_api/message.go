@@ -1,37 +1,57 @@
 package tg_bot_api
 
 import (
+	"context"
 	"github.com/Enthreeka/tg-question-bot/pkg/logger"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
+	"time"
 )
 
+// Message - ctx ограничивает ожидание лимита и паузы после 429, но не уже отправленный запрос
 type Message interface {
-	SendNewMessage(chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error)
-	SendEditMessage(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error)
-	SendDocument(chatID int64, fileName string, fileIDBytes *[]byte, text string) (int, error)
+	SendNewMessage(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error)
+	SendEditMessage(ctx context.Context, chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error)
+	SendDocument(ctx context.Context, chatID int64, fileName string, fileIDBytes *[]byte, text string) (int, error)
+	// SendEditMarkup - заменяет только клавиатуру сообщения, пустая markup убирает ее
+	SendEditMarkup(ctx context.Context, chatID int64, messageID int, markup tgbotapi.InlineKeyboardMarkup) error
+	DeleteMessage(ctx context.Context, chatID int64, messageID int) error
+	// SetCommands - меню команд для scope, DeleteCommands - сбрасывает его к меню более общего scope
+	SetCommands(ctx context.Context, scope tgbotapi.BotCommandScope, commands []tgbotapi.BotCommand) error
+	DeleteCommands(ctx context.Context, scope tgbotapi.BotCommandScope) error
 }
 
+// TelegramMsg - все запросы проходят через общий Limiter, поэтому рассылки, уведомления
+// и ответы пользователям можно отправлять из разных горутин
 type TelegramMsg struct {
-	log *logger.Logger
-	bot *tgbotapi.BotAPI
+	log     *logger.Logger
+	bot     *tgbotapi.BotAPI
+	limiter *Limiter
+	// after - пауза перед повтором после 429, подменяется в тестах
+	after func(time.Duration) <-chan time.Time
 }
 
 func NewMessageSetting(bot *tgbotapi.BotAPI, log *logger.Logger) *TelegramMsg {
 	return &TelegramMsg{
-		bot: bot,
-		log: log,
+		bot:     bot,
+		log:     log,
+		limiter: NewLimiter(),
+		after:   time.After,
 	}
 }
 
-func (t *TelegramMsg) SendNewMessage(chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
+func (t *TelegramMsg) SendNewMessage(ctx context.Context, chatID int64, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = tgbotapi.ModeHTML
 	if markup != nil {
 		msg.ReplyMarkup = &markup
 	}
 
-	sendMsg, err := t.bot.Send(msg)
+	var sendMsg tgbotapi.Message
+	err := t.send(ctx, chatID, func() (err error) {
+		sendMsg, err = t.bot.Send(msg)
+		return err
+	})
 	if err != nil {
 		t.log.Error("failed to send message", zap.Error(err))
 		return 0, err
@@ -40,7 +60,7 @@ func (t *TelegramMsg) SendNewMessage(chatID int64, markup *tgbotapi.InlineKeyboa
 	return sendMsg.MessageID, nil
 }
 
-func (t *TelegramMsg) SendEditMessage(chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
+func (t *TelegramMsg) SendEditMessage(ctx context.Context, chatID int64, messageID int, markup *tgbotapi.InlineKeyboardMarkup, text string) (int, error) {
 	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
 	msg.ParseMode = tgbotapi.ModeHTML
 
@@ -48,7 +68,11 @@ func (t *TelegramMsg) SendEditMessage(chatID int64, messageID int, markup *tgbot
 		msg.ReplyMarkup = markup
 	}
 
-	sendMsg, err := t.bot.Send(msg)
+	var sendMsg tgbotapi.Message
+	err := t.send(ctx, chatID, func() (err error) {
+		sendMsg, err = t.bot.Send(msg)
+		return err
+	})
 	if err != nil {
 		t.log.Error("failed to send msg: %v", err)
 		return 0, err
@@ -57,7 +81,7 @@ func (t *TelegramMsg) SendEditMessage(chatID int64, messageID int, markup *tgbot
 	return sendMsg.MessageID, nil
 }
 
-func (t *TelegramMsg) SendDocument(chatID int64, fileName string, fileIDBytes *[]byte, text string) (int, error) {
+func (t *TelegramMsg) SendDocument(ctx context.Context, chatID int64, fileName string, fileIDBytes *[]byte, text string) (int, error) {
 	msg := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
 		Name:  fileName,
 		Bytes: *fileIDBytes,
@@ -65,7 +89,11 @@ func (t *TelegramMsg) SendDocument(chatID int64, fileName string, fileIDBytes *[
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.Caption = text
 
-	sendMsg, err := t.bot.Send(msg)
+	var sendMsg tgbotapi.Message
+	err := t.send(ctx, chatID, func() (err error) {
+		sendMsg, err = t.bot.Send(msg)
+		return err
+	})
 	if err != nil {
 		t.log.Error("failed to send msg: %v", err)
 		return 0, err
@@ -73,3 +101,39 @@ func (t *TelegramMsg) SendDocument(chatID int64, fileName string, fileIDBytes *[
 
 	return sendMsg.MessageID, nil
 }
+
+func (t *TelegramMsg) SendEditMarkup(ctx context.Context, chatID int64, messageID int, markup tgbotapi.InlineKeyboardMarkup) error {
+	err := t.send(ctx, chatID, func() error {
+		_, err := t.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, markup))
+		return err
+	})
+	if err != nil {
+		t.log.Error("failed to edit markup of message %d: %v", messageID, err)
+	}
+	return err
+}
+
+func (t *TelegramMsg) DeleteMessage(ctx context.Context, chatID int64, messageID int) error {
+	err := t.send(ctx, chatID, func() error {
+		_, err := t.bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+		return err
+	})
+	if err != nil {
+		t.log.Error("failed to delete message %d: %v", messageID, err)
+	}
+	return err
+}
+
+func (t *TelegramMsg) SetCommands(ctx context.Context, scope tgbotapi.BotCommandScope, commands []tgbotapi.BotCommand) error {
+	return t.send(ctx, 0, func() error {
+		_, err := t.bot.Request(tgbotapi.NewSetMyCommandsWithScope(scope, commands...))
+		return err
+	})
+}
+
+func (t *TelegramMsg) DeleteCommands(ctx context.Context, scope tgbotapi.BotCommandScope) error {
+	return t.send(ctx, 0, func() error {
+		_, err := t.bot.Request(tgbotapi.NewDeleteMyCommandsWithScope(scope))
+		return err
+	})
+}
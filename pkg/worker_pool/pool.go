@@ -0,0 +1,131 @@
+package worker_pool
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+)
+
+var ErrClosed = errors.New("worker pool is closed")
+
+// Pool - фиксированное число обработчиков со своими очередями. Задачи с одинаковым ключом
+// попадают к одному обработчику и выполняются строго в порядке поступления
+type Pool struct {
+	shards  []chan func()
+	wg      sync.WaitGroup
+	onPanic PanicHandler
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// PanicHandler - вызывается с восстановленным значением и стеком, если задача паникует.
+// Обработчик после этого продолжает выполнять следующие задачи
+type PanicHandler func(recovered any, stack []byte)
+
+// New - onPanic может быть nil, паника задачи тогда только не дает упасть процессу
+func New(workers, queueSize int, onPanic PanicHandler) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool{
+		shards:  make([]chan func(), workers),
+		onPanic: onPanic,
+	}
+	for i := range p.shards {
+		p.shards[i] = make(chan func(), queueSize)
+
+		p.wg.Add(1)
+		go p.work(p.shards[i])
+	}
+
+	return p
+}
+
+func (p *Pool) work(tasks <-chan func()) {
+	defer p.wg.Done()
+	for task := range tasks {
+		p.run(task)
+	}
+}
+
+func (p *Pool) run(task func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil && p.onPanic != nil {
+			p.onPanic(recovered, debug.Stack())
+		}
+	}()
+
+	task()
+}
+
+// Submit - ставит задачу в очередь обработчика key. Если очередь заполнена, ждет освобождения места
+func (p *Pool) Submit(ctx context.Context, key int64, task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.shards[p.shard(key)] <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsFull - очередь обработчика key заполнена и Submit будет ждать
+func (p *Pool) IsFull(key int64) bool {
+	shard := p.shards[p.shard(key)]
+	return len(shard) == cap(shard)
+}
+
+// Depth - число задач, ожидающих в очереди каждого обработчика
+func (p *Pool) Depth() []int {
+	depth := make([]int, len(p.shards))
+	for i, shard := range p.shards {
+		depth[i] = len(shard)
+	}
+	return depth
+}
+
+// Close - перестает принимать задачи и ждет выполнения уже поставленных
+func (p *Pool) Close() {
+	_ = p.Shutdown(context.Background())
+}
+
+// Shutdown - как Close, но ждет не дольше ctx. Ошибка означает, что часть задач еще выполняется
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, shard := range p.shards {
+			close(shard)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) shard(key int64) int {
+	return int(uint64(key) % uint64(len(p.shards)))
+}
@@ -0,0 +1,178 @@
+package worker_pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolRecoversPanic(t *testing.T) {
+	recovered := make(chan any, 1)
+	p := New(1, 1, func(value any, stack []byte) {
+		if len(stack) == 0 {
+			t.Error("onPanic got empty stack")
+		}
+		recovered <- value
+	})
+
+	ctx := context.Background()
+	done := make(chan struct{})
+	if err := p.Submit(ctx, 1, func() { panic("task failed") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Submit(ctx, 1, func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	select {
+	case value := <-recovered:
+		if value != "task failed" {
+			t.Fatalf("onPanic value = %v, want task failed", value)
+		}
+	default:
+		t.Fatal("onPanic was not called")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("task after panic did not run")
+	}
+}
+
+func TestPoolWithoutPanicHandler(t *testing.T) {
+	p := New(1, 1, nil)
+	if err := p.Submit(context.Background(), 1, func() { panic("task failed") }); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+}
+
+func TestPoolKeepsOrderPerKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		keys    []int64
+	}{
+		{name: "one worker", workers: 1, keys: []int64{1, 2, 3}},
+		{name: "more keys than workers", workers: 2, keys: []int64{1, 2, 3, 4, 5}},
+		{name: "negative keys", workers: 3, keys: []int64{-100, -200, 7}},
+	}
+
+	const tasksPerKey = 50
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.workers, 4, nil)
+
+			// каждая задача пишет в слайс своего ключа, сама map после заполнения только читается
+			got := make(map[int64]*[]int, len(tt.keys))
+			for _, key := range tt.keys {
+				got[key] = new([]int)
+			}
+
+			ctx := context.Background()
+			for i := 0; i < tasksPerKey; i++ {
+				for _, key := range tt.keys {
+					key, i := key, i
+					if err := p.Submit(ctx, key, func() { *got[key] = append(*got[key], i) }); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			p.Close()
+
+			for key, order := range got {
+				if len(*order) != tasksPerKey {
+					t.Fatalf("key %d: %d tasks ran, want %d", key, len(*order), tasksPerKey)
+				}
+				for i, value := range *order {
+					if value != i {
+						t.Fatalf("key %d: task %d ran at position %d", key, value, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPoolShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		// block - задача не завершится до конца теста
+		block   bool
+		wantErr bool
+	}{
+		{name: "queued tasks finish", timeout: time.Second},
+		{name: "stuck task exceeds timeout", timeout: 10 * time.Millisecond, block: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(1, 2, nil)
+			release := make(chan struct{})
+			defer close(release)
+
+			ctx := context.Background()
+			ran := make(chan struct{}, 2)
+			if err := p.Submit(ctx, 1, func() {
+				if tt.block {
+					<-release
+				}
+				ran <- struct{}{}
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Submit(ctx, 1, func() { ran <- struct{}{} }); err != nil {
+				t.Fatal(err)
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(ctx, tt.timeout)
+			defer cancel()
+
+			err := p.Shutdown(shutdownCtx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Shutdown error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(ran) != 2 {
+				t.Fatalf("%d tasks ran before Shutdown returned, want 2", len(ran))
+			}
+
+			if err := p.Submit(ctx, 1, func() {}); !errors.Is(err, ErrClosed) {
+				t.Fatalf("Submit after Shutdown error = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestPoolSubmitWaitsForQueue(t *testing.T) {
+	p := New(1, 0, nil)
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		p.Close()
+	}()
+
+	started := make(chan struct{})
+	if err := p.Submit(context.Background(), 1, func() {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if !p.IsFull(1) {
+		t.Fatal("IsFull = false for a busy worker without queue")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.Submit(ctx, 1, func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit to a full queue error = %v, want context.DeadlineExceeded", err)
+	}
+}